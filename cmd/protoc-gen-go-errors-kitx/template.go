@@ -25,6 +25,15 @@ func Error{{ .CamelValue }}(format string, args ...interface{}) *errorx.Error {
 }
 
 {{- end }}
+
+// {{ .Name }}FromError returns the {{ .Name }} of err, e.g. an error decoded by kitx http or grpc clients
+func {{ .Name }}FromError(err error) ({{ .Name }}, bool) {
+	if err == nil {
+		return 0, false
+	}
+	v, ok := {{ .Name }}_value[errorx.FromError(err).Reason]
+	return {{ .Name }}(v), ok
+}
 `
 )
 
@@ -38,6 +47,7 @@ type errorInfo struct {
 }
 
 type errorWrapper struct {
+	Name   string // go name of the enum
 	Errors []*errorInfo
 }
 
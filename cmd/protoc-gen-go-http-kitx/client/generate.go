@@ -0,0 +1,3 @@
+package client
+
+//go:generate protoc -I . -I ../../../third_party --go_out=paths=source_relative:. ./client.proto
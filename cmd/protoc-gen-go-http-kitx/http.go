@@ -5,6 +5,9 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/sado0823/go-kitx/cmd/protoc-gen-go-http-kitx/client"
 
 	"google.golang.org/genproto/googleapis/api/annotations"
 	"google.golang.org/protobuf/compiler/protogen"
@@ -17,12 +20,19 @@ const (
 	contextPackage       = protogen.GoImportPath("context")
 	transportHTTPPackage = protogen.GoImportPath("github.com/sado0823/go-kitx/transport/http")
 	bindingPackage       = protogen.GoImportPath("github.com/sado0823/go-kitx/transport/http/binding")
+	timePackage          = protogen.GoImportPath("time")
 )
 
 var methodSets = make(map[string]int)
 
+// streamFormats maps the stream option to the format used by the generated code
+var streamFormats = map[string]string{
+	"sse":    "StreamSSE",
+	"ndjson": "StreamNDJSON",
+}
+
 // generateFile generates a _http.pb.go file containing kitx errorx definitions.
-func generateFile(gen *protogen.Plugin, file *protogen.File, omitempty bool) *protogen.GeneratedFile {
+func generateFile(gen *protogen.Plugin, file *protogen.File, omitempty bool, streamFormat string) *protogen.GeneratedFile {
 	if len(file.Services) == 0 || (omitempty && !hasHTTPRule(file.Services)) {
 		return nil
 	}
@@ -40,12 +50,12 @@ func generateFile(gen *protogen.Plugin, file *protogen.File, omitempty bool) *pr
 	g.P()
 	g.P("package ", file.GoPackageName)
 	g.P()
-	generateFileContent(gen, file, g, omitempty)
+	generateFileContent(gen, file, g, omitempty, streamFormat)
 	return g
 }
 
 // generateFileContent generates the kitx errorx definitions, excluding the package statement.
-func generateFileContent(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, omitempty bool) {
+func generateFileContent(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, omitempty bool, streamFormat string) {
 	if len(file.Services) == 0 {
 		return
 	}
@@ -58,23 +68,25 @@ func generateFileContent(gen *protogen.Plugin, file *protogen.File, g *protogen.
 	g.P()
 
 	for _, service := range file.Services {
-		genService(gen, file, g, service, omitempty)
+		genService(gen, file, g, service, omitempty, streamFormat)
 	}
 }
 
-func genService(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, service *protogen.Service, omitempty bool) {
+func genService(gen *protogen.Plugin, file *protogen.File, g *protogen.GeneratedFile, service *protogen.Service, omitempty bool, streamFormat string) {
 	if service.Desc.Options().(*descriptorpb.ServiceOptions).GetDeprecated() {
 		g.P("//")
 		g.P(deprecationComment)
 	}
 	// HTTP Server.
 	sd := &serviceDesc{
-		ServiceType: service.GoName,
-		ServiceName: string(service.Desc.FullName()),
-		Metadata:    file.Desc.Path(),
+		ServiceType:  service.GoName,
+		ServiceName:  string(service.Desc.FullName()),
+		Metadata:     file.Desc.Path(),
+		StreamFormat: streamFormat,
 	}
 	for _, method := range service.Methods {
-		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+		// server streaming methods are served as server-sent events
+		if method.Desc.IsStreamingClient() {
 			continue
 		}
 		rule, ok := proto.GetExtension(method.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
@@ -96,7 +108,7 @@ func genService(gen *protogen.Plugin, file *protogen.File, g *protogen.Generated
 func hasHTTPRule(services []*protogen.Service) bool {
 	for _, service := range services {
 		for _, method := range service.Methods {
-			if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			if method.Desc.IsStreamingClient() {
 				continue
 			}
 			rule, ok := proto.GetExtension(method.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
@@ -197,7 +209,7 @@ func buildMethodDesc(g *protogen.GeneratedFile, m *protogen.Method, method, path
 			}
 		}
 	}
-	return &methodDesc{
+	md := &methodDesc{
 		Name:         m.GoName,
 		OriginalName: string(m.Desc.Name()),
 		Num:          methodSets[m.GoName],
@@ -206,7 +218,86 @@ func buildMethodDesc(g *protogen.GeneratedFile, m *protogen.Method, method, path
 		Path:         path,
 		Method:       method,
 		HasVars:      len(vars) > 0,
+		Stream:       m.Desc.IsStreamingServer(),
+	}
+	if !md.Stream {
+		md.CallOptions = buildCallOptions(g, m, method, path)
+	}
+	return md
+}
+
+// buildCallOptions returns the default call options of the client method from its proto options
+func buildCallOptions(g *protogen.GeneratedFile, m *protogen.Method, method, path string) []string {
+	var opts []string
+	idempotent := isIdempotent(m, method)
+	if idempotent {
+		opts = append(opts, "http.Idempotent(true)")
+	}
+	options, ok := proto.GetExtension(m.Desc.Options(), client.E_Options).(*client.Options)
+	if !ok || options == nil {
+		return opts
+	}
+	if timeout := options.GetTimeout(); timeout != nil {
+		opts = append(opts, fmt.Sprintf("http.Timeout(%s)", durationExpr(g, timeout.AsDuration())))
+	}
+	if retry := options.GetRetry(); retry != nil {
+		switch {
+		case retry.GetAttempts() < 2:
+			_, _ = fmt.Fprintf(os.Stderr, "\u001B[31mWARN\u001B[m: %s %s retry attempts should be greater than 1.\n", method, path)
+		case !idempotent:
+			_, _ = fmt.Fprintf(os.Stderr, "\u001B[31mWARN\u001B[m: %s %s is not idempotent and won't be retried, set its idempotency_level.\n", method, path)
+		default:
+			policy := fmt.Sprintf("Attempts: %d", retry.GetAttempts())
+			if backoff := retry.GetBackoff(); backoff != nil {
+				policy += fmt.Sprintf(", Backoff: %s", durationExpr(g, backoff.AsDuration()))
+			}
+			if len(retry.GetCodes()) > 0 {
+				codes := make([]string, 0, len(retry.GetCodes()))
+				for _, code := range retry.GetCodes() {
+					codes = append(codes, fmt.Sprint(code))
+				}
+				policy += fmt.Sprintf(", Codes: []int{%s}", strings.Join(codes, ", "))
+			}
+			opts = append(opts, fmt.Sprintf("http.Retry(http.RetryPolicy{%s})", policy))
+		}
+	}
+	return opts
+}
+
+// isIdempotent uses the idempotency_level of the method, or infers it from the http method
+func isIdempotent(m *protogen.Method, method string) bool {
+	if level := m.Desc.Options().(*descriptorpb.MethodOptions).GetIdempotencyLevel(); level != descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN {
+		return true
+	}
+	switch method {
+	case "GET", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// durationExpr returns d as a go expression, e.g. 500 * time.Millisecond
+func durationExpr(g *protogen.GeneratedFile, d time.Duration) string {
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{time.Hour, "Hour"},
+		{time.Minute, "Minute"},
+		{time.Second, "Second"},
+		{time.Millisecond, "Millisecond"},
+		{time.Microsecond, "Microsecond"},
+	}
+	for _, u := range units {
+		if d > 0 && d%u.unit == 0 {
+			ident := g.QualifiedGoIdent(timePackage.Ident(u.name))
+			if d == u.unit {
+				return ident
+			}
+			return fmt.Sprintf("%d * %s", d/u.unit, ident)
+		}
 	}
+	return fmt.Sprintf("%s(%d)", g.QualifiedGoIdent(timePackage.Ident("Duration")), int64(d))
 }
 
 func buildPathVars(path string) (res map[string]*string) {
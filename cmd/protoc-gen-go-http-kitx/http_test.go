@@ -2,7 +2,20 @@ package main
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/cmd/protoc-gen-go-http-kitx/client"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/pluginpb"
 )
 
 func TestNoParameters(t *testing.T) {
@@ -85,3 +98,132 @@ func TestIterationMiddle(t *testing.T) {
 		t.Fatal(`replacePath("message.name", "messages/*", path) should be "/test/{message.name:messages/.*}/books"`)
 	}
 }
+
+func collect(fd protoreflect.FileDescriptor, seen map[string]bool, out *[]*descriptorpb.FileDescriptorProto) {
+	if seen[fd.Path()] {
+		return
+	}
+	seen[fd.Path()] = true
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		collect(imports.Get(i).FileDescriptor, seen, out)
+	}
+	*out = append(*out, protodesc.ToFileDescriptorProto(fd))
+}
+
+func methodOptions(rule *annotations.HttpRule, opts *client.Options, level descriptorpb.MethodOptions_IdempotencyLevel) *descriptorpb.MethodOptions {
+	mo := &descriptorpb.MethodOptions{IdempotencyLevel: level.Enum()}
+	proto.SetExtension(mo, annotations.E_Http, rule)
+	if opts != nil {
+		proto.SetExtension(mo, client.E_Options, opts)
+	}
+	return mo
+}
+
+func TestGenerateCallOptions(t *testing.T) {
+	var files []*descriptorpb.FileDescriptorProto
+	seen := make(map[string]bool)
+	collect(annotations.File_google_api_annotations_proto, seen, &files)
+	collect(client.File_client_proto, seen, &files)
+
+	retry := &client.Retry{Attempts: 3, Backoff: durationpb.New(100 * time.Millisecond), Codes: []int32{503}}
+	method := func(name string, rule *annotations.HttpRule, opts *client.Options, level descriptorpb.MethodOptions_IdempotencyLevel) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".test.Item"),
+			OutputType: proto.String(".test.Item"),
+			Options:    methodOptions(rule, opts, level),
+		}
+	}
+	files = append(files, &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/item.proto"),
+		Package:    proto.String("test"),
+		Dependency: []string{"google/api/annotations.proto", "client.proto"},
+		Syntax:     proto.String("proto3"),
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("example.com/test;test")},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Item"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("id"),
+				JsonName: proto.String("id"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			}},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Items"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("GetItem",
+					&annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/items/{id}"}},
+					&client.Options{Timeout: durationpb.New(1500 * time.Millisecond), Retry: retry},
+					descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN),
+				method("CreateItem",
+					&annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/v1/items"}, Body: "*"},
+					&client.Options{Timeout: durationpb.New(2 * time.Second), Retry: retry},
+					descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN),
+				method("TouchItem",
+					&annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/v1/items/{id}/touch"}, Body: "*"},
+					&client.Options{Retry: &client.Retry{Attempts: 2}},
+					descriptorpb.MethodOptions_IDEMPOTENT),
+				method("ListItems",
+					&annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/v1/items:list"}, Body: "*"},
+					nil,
+					descriptorpb.MethodOptions_IDEMPOTENCY_UNKNOWN),
+			},
+		}},
+	})
+
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"test/item.proto"},
+		ProtoFile:      files,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	generateFile(gen, gen.FilesByPath["test/item.proto"], true, "StreamSSE")
+	resp := gen.Response()
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+	content := resp.File[0].GetContent()
+
+	for _, want := range []string{
+		`"time"`,
+		"http.Timeout(1500 * time.Millisecond),",
+		"http.Retry(http.RetryPolicy{Attempts: 3, Backoff: 100 * time.Millisecond, Codes: []int{503}}),",
+		// not idempotent, no retry
+		"http.Timeout(2 * time.Second),\n\t}",
+		// idempotency_level
+		"http.Idempotent(true),\n\t\thttp.Retry(http.RetryPolicy{Attempts: 2}),",
+		// no options
+		"opts = append(c.opts.Get(OperationItemsListItems), opts...)",
+		"func NewItemsHTTPClient(client *http.Client, opts ...http.MethodOption) ItemsHTTPClient {",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("generated code should contain %q:\n%s", want, content)
+		}
+	}
+	if strings.Count(content, "http.Idempotent(true)") != 2 {
+		t.Fatal("only GetItem and TouchItem are idempotent")
+	}
+}
+
+func TestDurationExpr(t *testing.T) {
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := gen.NewGeneratedFile("test.go", "example.com/test")
+	for d, want := range map[time.Duration]string{
+		time.Second:             "time.Second",
+		90 * time.Second:        "90 * time.Second",
+		2 * time.Minute:         "2 * time.Minute",
+		1500 * time.Microsecond: "1500 * time.Microsecond",
+		1500:                    "time.Duration(1500)",
+	} {
+		if got := durationExpr(g, d); got != want {
+			t.Fatalf("durationExpr(%s) should be %s, got %s", d, want, got)
+		}
+	}
+}
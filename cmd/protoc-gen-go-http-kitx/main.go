@@ -11,6 +11,7 @@ import (
 var (
 	showVersion = flag.Bool("version", false, "print the version and exit")
 	omitempty   = flag.Bool("omitempty", true, "omit if google.api is empty")
+	stream      = flag.String("stream", "sse", "format of server streaming methods, sse or ndjson")
 )
 
 func main() {
@@ -22,12 +23,16 @@ func main() {
 	protogen.Options{
 		ParamFunc: flag.CommandLine.Set,
 	}.Run(func(gen *protogen.Plugin) error {
+		format, ok := streamFormats[*stream]
+		if !ok {
+			return fmt.Errorf("protoc-gen-go-http-kitx: unknown stream format %q, should be sse or ndjson", *stream)
+		}
 		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
 		for _, f := range gen.Files {
 			if !f.Generate {
 				continue
 			}
-			generateFile(gen, f, *omitempty)
+			generateFile(gen, f, *omitempty, format)
 		}
 		return nil
 	})
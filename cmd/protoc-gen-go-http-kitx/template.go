@@ -11,6 +11,7 @@ var (
 	httpTemplate = `
 {{$svrType := .ServiceType}}
 {{$svrName := .ServiceName}}
+{{$streamFormat := .StreamFormat}}
 
 {{- range .MethodSets}}
 const Operation{{$svrType}}{{.OriginalName}} = "/{{$svrName}}/{{.OriginalName}}"
@@ -18,7 +19,11 @@ const Operation{{$svrType}}{{.OriginalName}} = "/{{$svrName}}/{{.OriginalName}}"
 
 type {{.ServiceType}}HTTPServer interface {
 {{- range .MethodSets}}
+	{{- if .Stream}}
+	{{.Name}}(*{{.Request}}, {{$svrType}}_{{.Name}}Server) error
+	{{- else}}
 	{{.Name}}(context.Context, *{{.Request}}) (*{{.Reply}}, error)
+	{{- end}}
 {{- end}}
 }
 
@@ -53,6 +58,11 @@ func _{{$svrType}}_{{.Name}}{{.Num}}_HTTP_Handler(srv {{$svrType}}HTTPServer) fu
 		}
 		{{- end}}
 		http.SetOperation(ctx,Operation{{$svrType}}{{.OriginalName}})
+		{{- if .Stream}}
+		return http.ServeStream(ctx, http.{{$streamFormat}}, &in, func(stream *http.ServerStream, req interface{}) error {
+			return srv.{{.Name}}(req.(*{{.Request}}), &_{{$svrType}}_{{.Name}}_HTTP_ServerStream{stream})
+		})
+		{{- else}}
 		h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
 			return srv.{{.Name}}(ctx, req.(*{{.Request}}))
 		})
@@ -62,29 +72,94 @@ func _{{$svrType}}_{{.Name}}{{.Num}}_HTTP_Handler(srv {{$svrType}}HTTPServer) fu
 		}
 		reply := out.(*{{.Reply}})
 		return ctx.Result(200, reply{{.ResponseBody}})
+		{{- end}}
+	}
+}
+{{end}}
+
+{{- range .MethodSets}}
+{{- if .Stream}}
+type _{{$svrType}}_{{.Name}}_HTTP_ServerStream struct {
+	*http.ServerStream
+}
+
+func (x *_{{$svrType}}_{{.Name}}_HTTP_ServerStream) Send(m *{{.Reply}}) error {
+	return x.SendMsg(m)
+}
+
+type {{$svrType}}_{{.Name}}HTTPStream interface {
+	Recv() (*{{.Reply}}, error)
+	Close() error
+}
+
+type _{{$svrType}}_{{.Name}}_HTTP_ClientStream struct {
+	*http.ClientStream
+}
+
+func (x *_{{$svrType}}_{{.Name}}_HTTP_ClientStream) Recv() (*{{.Reply}}, error) {
+	m := new({{.Reply}})
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
 	}
+	return m, nil
 }
 {{end}}
+{{- end}}
 
 type {{.ServiceType}}HTTPClient interface {
 {{- range .MethodSets}}
+	{{- if .Stream}}
+	{{.Name}}(ctx context.Context, req *{{.Request}}, opts ...http.CallOption) ({{$svrType}}_{{.Name}}HTTPStream, error)
+	{{- else}}
 	{{.Name}}(ctx context.Context, req *{{.Request}}, opts ...http.CallOption) (rsp *{{.Reply}}, err error) 
+	{{- end}}
 {{- end}}
 }
 	
 type {{.ServiceType}}HTTPClientImpl struct{
-	cc *http.Client
+	cc   *http.Client
+	opts http.MethodCallOptions
 }
-	
-func New{{.ServiceType}}HTTPClient (client *http.Client) {{.ServiceType}}HTTPClient {
-	return &{{.ServiceType}}HTTPClientImpl{client}
+
+// New{{.ServiceType}}HTTPClient opts override the defaults from proto options per method,
+// e.g. a http.BodyEncoder of a method
+func New{{.ServiceType}}HTTPClient (client *http.Client, opts ...http.MethodOption) {{.ServiceType}}HTTPClient {
+	return &{{.ServiceType}}HTTPClientImpl{cc: client, opts: http.NewMethodCallOptions(opts...)}
 }
 
 {{range .MethodSets}}
+{{- if .Stream}}
+func (c *{{$svrType}}HTTPClientImpl) {{.Name}}(ctx context.Context, in *{{.Request}}, opts ...http.CallOption) ({{$svrType}}_{{.Name}}HTTPStream, error) {
+	pattern := "{{.Path}}"
+	path := binding.EncodeURL(pattern, in, {{not .HasBody}})
+	opts = append(c.opts.Get(Operation{{$svrType}}{{.OriginalName}}), opts...)
+	opts = append(opts, http.Operation(Operation{{$svrType}}{{.OriginalName}}))
+	opts = append(opts, http.PathTemplate(pattern))
+	{{if .HasBody -}}
+	stream, err := c.cc.Stream(ctx, "{{.Method}}", path, in{{.Body}}, http.{{$streamFormat}}, opts...)
+	{{else -}}
+	stream, err := c.cc.Stream(ctx, "{{.Method}}", path, nil, http.{{$streamFormat}}, opts...)
+	{{end -}}
+	if err != nil {
+		return nil, err
+	}
+	return &_{{$svrType}}_{{.Name}}_HTTP_ClientStream{stream}, nil
+}
+{{else}}
 func (c *{{$svrType}}HTTPClientImpl) {{.Name}}(ctx context.Context, in *{{.Request}}, opts ...http.CallOption) (*{{.Reply}}, error) {
 	var out {{.Reply}}
 	pattern := "{{.Path}}"
 	path := binding.EncodeURL(pattern, in, {{not .HasBody}})
+	{{- if .CallOptions}}
+	defaults := []http.CallOption{
+		{{- range .CallOptions}}
+		{{.}},
+		{{- end}}
+	}
+	opts = append(append(defaults, c.opts.Get(Operation{{$svrType}}{{.OriginalName}})...), opts...)
+	{{- else}}
+	opts = append(c.opts.Get(Operation{{$svrType}}{{.OriginalName}}), opts...)
+	{{- end}}
 	opts = append(opts, http.Operation(Operation{{$svrType}}{{.OriginalName}}))
 	opts = append(opts, http.PathTemplate(pattern))
 	{{if .HasBody -}}
@@ -98,15 +173,17 @@ func (c *{{$svrType}}HTTPClientImpl) {{.Name}}(ctx context.Context, in *{{.Reque
 	return &out, err
 }
 {{end}}
+{{- end}}
 `
 )
 
 type serviceDesc struct {
-	ServiceType string // Greeter
-	ServiceName string // helloworld.Greeter
-	Metadata    string // api/helloworld/helloworld.proto
-	Methods     []*methodDesc
-	MethodSets  map[string]*methodDesc
+	ServiceType  string // Greeter
+	ServiceName  string // helloworld.Greeter
+	Metadata     string // api/helloworld/helloworld.proto
+	StreamFormat string // StreamSSE or StreamNDJSON, format of server streaming methods
+	Methods      []*methodDesc
+	MethodSets   map[string]*methodDesc
 }
 
 type methodDesc struct {
@@ -120,6 +197,8 @@ type methodDesc struct {
 	Path         string
 	Method       string
 	HasVars      bool
+	Stream       bool     // server streaming
+	CallOptions  []string // default call options of the client from proto options
 	HasBody      bool
 	Body         string
 	ResponseBody string
@@ -0,0 +1,523 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// errorSchema is the errorx model written by the http ErrorEncoder
+	errorSchema = "errors.Status"
+
+	sseContentType    = "text/event-stream"
+	ndjsonContentType = "application/x-ndjson"
+)
+
+type options struct {
+	omitempty   bool
+	filename    string
+	title       string
+	description string
+	version     string
+	protoNames  bool
+}
+
+type generator struct {
+	opts  *options
+	rules *rules
+	doc   *Document
+	// operation ids in use, for additional bindings
+	operationIDs map[string]int
+}
+
+// generate writes one spec for all the files to generate
+func generate(gen *protogen.Plugin, opts *options) error {
+	g := &generator{
+		opts:  opts,
+		rules: newRules(gen),
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Info: Info{
+				Title:       opts.title,
+				Description: opts.description,
+				Version:     opts.version,
+			},
+			Paths:      make(map[string]*PathItem),
+			Components: Components{Schemas: make(map[string]*Schema)},
+		},
+		operationIDs: make(map[string]int),
+	}
+
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		for _, service := range f.Services {
+			g.addService(service)
+		}
+	}
+	if len(g.doc.Paths) == 0 {
+		return nil
+	}
+	if g.doc.Info.Title == "" {
+		names := make([]string, 0, len(g.doc.Tags))
+		for _, tag := range g.doc.Tags {
+			names = append(names, tag.Name)
+		}
+		g.doc.Info.Title = strings.Join(names, ", ") + " API"
+	}
+	g.addErrorSchema()
+
+	var (
+		data []byte
+		err  error
+	)
+	if strings.HasSuffix(opts.filename, ".json") {
+		data, err = json.MarshalIndent(g.doc, "", "  ")
+	} else {
+		data, err = yaml.Marshal(g.doc)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = gen.NewGeneratedFile(opts.filename, "").Write(data)
+	return err
+}
+
+func (g *generator) addService(service *protogen.Service) {
+	var added bool
+	for _, method := range service.Methods {
+		// client streaming can't be served over http
+		if method.Desc.IsStreamingClient() {
+			continue
+		}
+		rule, ok := proto.GetExtension(method.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if rule != nil && ok {
+			g.addRule(service, method, rule)
+			for _, bind := range rule.AdditionalBindings {
+				g.addRule(service, method, bind)
+			}
+			added = true
+		} else if !g.opts.omitempty {
+			path := fmt.Sprintf("/%s/%s", service.Desc.FullName(), method.Desc.Name())
+			g.addOperation(service, method, "POST", path, "*", "")
+			added = true
+		}
+	}
+	if added {
+		g.doc.Tags = append(g.doc.Tags, &Tag{
+			Name:        service.GoName,
+			Description: comment(service.Comments),
+		})
+	}
+}
+
+func (g *generator) addRule(service *protogen.Service, m *protogen.Method, rule *annotations.HttpRule) {
+	var path, method string
+	switch pattern := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		path, method = pattern.Get, "GET"
+	case *annotations.HttpRule_Put:
+		path, method = pattern.Put, "PUT"
+	case *annotations.HttpRule_Post:
+		path, method = pattern.Post, "POST"
+	case *annotations.HttpRule_Delete:
+		path, method = pattern.Delete, "DELETE"
+	case *annotations.HttpRule_Patch:
+		path, method = pattern.Patch, "PATCH"
+	case *annotations.HttpRule_Custom:
+		path, method = pattern.Custom.Path, strings.ToUpper(pattern.Custom.Kind)
+	}
+	g.addOperation(service, m, method, path, rule.Body, rule.ResponseBody)
+}
+
+func (g *generator) addOperation(service *protogen.Service, m *protogen.Method, method, path, body, responseBody string) {
+	id := fmt.Sprintf("%s_%s", service.GoName, m.GoName)
+	if n := g.operationIDs[id]; n > 0 {
+		g.operationIDs[id]++
+		id = fmt.Sprintf("%s%d", id, n)
+	} else {
+		g.operationIDs[id] = 1
+	}
+
+	desc := comment(m.Comments)
+	op := &Operation{
+		Tags:        []string{service.GoName},
+		Summary:     firstLine(desc),
+		Description: desc,
+		OperationID: id,
+		Responses:   make(map[string]*Response),
+		Deprecated:  m.Desc.Options().(*descriptorpb.MethodOptions).GetDeprecated(),
+	}
+
+	// path params
+	vars := buildPathVars(path)
+	excluded := make(map[string]bool, len(vars)+1)
+	for _, name := range pathVarNames(path) {
+		fd := findField(m.Input.Desc, name)
+		if fd == nil {
+			fmt.Fprintf(os.Stderr, "\u001B[31mERROR\u001B[m: The corresponding field '%s' declaration in message could not be found in '%s'\n", name, path)
+			os.Exit(2)
+		}
+		excluded[name] = true
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        name,
+			In:          "path",
+			Description: fieldComment(m.Input, name),
+			Required:    true,
+			Schema:      g.fieldSchema(fd),
+		})
+	}
+	path = templatePath(path)
+
+	// body and query params
+	switch body {
+	case "*":
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: g.messageSchema(m.Input.Desc)}},
+		}
+	case "":
+		op.Parameters = append(op.Parameters, g.queryParams(m.Input, "", excluded)...)
+	default:
+		fd := findField(m.Input.Desc, body)
+		if fd == nil {
+			fmt.Fprintf(os.Stderr, "\u001B[31mERROR\u001B[m: The body field '%s' declaration in message could not be found in '%s'\n", body, path)
+			os.Exit(2)
+		}
+		excluded[body] = true
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: g.fieldSchema(fd)}},
+		}
+		op.Parameters = append(op.Parameters, g.queryParams(m.Input, "", excluded)...)
+	}
+
+	// responses
+	reply := g.messageSchema(m.Output.Desc)
+	if responseBody != "" && responseBody != "*" {
+		if fd := findField(m.Output.Desc, responseBody); fd != nil {
+			reply = g.fieldSchema(fd)
+		}
+	}
+	ok := &Response{Description: "OK"}
+	if m.Desc.IsStreamingServer() {
+		ok.Description = "stream of " + string(m.Output.Desc.FullName()) + ", every message is a server-sent event or a line of {\"result\": message}"
+		ok.Content = map[string]*MediaType{
+			sseContentType:    {Schema: reply},
+			ndjsonContentType: {Schema: reply},
+		}
+	} else {
+		ok.Content = map[string]*MediaType{"application/json": {Schema: reply}}
+	}
+	op.Responses["200"] = ok
+	op.Responses["default"] = &Response{
+		Description: "error, code is the http status code and reason tells the error",
+		Content:     map[string]*MediaType{"application/json": {Schema: ref(errorSchema)}},
+	}
+
+	item, exist := g.doc.Paths[path]
+	if !exist {
+		item = &PathItem{}
+		g.doc.Paths[path] = item
+	}
+	if !item.set(method, op) {
+		fmt.Fprintf(os.Stderr, "\u001B[31mWARN\u001B[m: %s %s is not supported by openapi, skipped.\n", method, path)
+	}
+}
+
+// queryParams flattens the fields of msg as query params, nested messages use dotted names
+func (g *generator) queryParams(msg *protogen.Message, prefix string, excluded map[string]bool) []*Parameter {
+	var params []*Parameter
+	for _, field := range msg.Fields {
+		fd := field.Desc
+		path := prefix + string(fd.Name())
+		if excluded[path] || fd.IsMap() {
+			continue
+		}
+		name := prefix + g.fieldName(fd)
+		if fd.Kind() == protoreflect.MessageKind && !fd.IsList() && wellKnown(fd.Message()) == nil {
+			// avoid infinite recursion of recursive messages
+			if !strings.Contains(prefix, string(fd.Name())+".") {
+				params = append(params, g.queryParams(field.Message, name+".", excluded)...)
+			}
+			continue
+		}
+		params = append(params, &Parameter{
+			Name:        name,
+			In:          "query",
+			Description: comment(field.Comments),
+			Schema:      g.fieldSchema(fd),
+		})
+	}
+	return params
+}
+
+// messageSchema returns the schema of md, a reference unless it's a well known type
+func (g *generator) messageSchema(md protoreflect.MessageDescriptor) *Schema {
+	if schema := wellKnown(md); schema != nil {
+		return schema
+	}
+	name := string(md.FullName())
+	if _, ok := g.doc.Components.Schemas[name]; ok {
+		return ref(name)
+	}
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	// registered before the fields for recursive messages
+	g.doc.Components.Schemas[name] = schema
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		name := g.fieldName(fd)
+		fs := g.fieldSchema(fd)
+		for _, behavior := range fieldBehaviors(fd) {
+			switch behavior {
+			case annotations.FieldBehavior_REQUIRED:
+				schema.Required = append(schema.Required, name)
+			case annotations.FieldBehavior_OUTPUT_ONLY:
+				fs.ReadOnly = true
+			}
+		}
+		if g.rules.required(fd) && !contains(schema.Required, name) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = fs
+	}
+	return ref(name)
+}
+
+// fieldSchema returns the schema of the field, with the constraints of its validate rules
+func (g *generator) fieldSchema(fd protoreflect.FieldDescriptor) *Schema {
+	var schema *Schema
+	switch {
+	case fd.IsMap():
+		schema = &Schema{Type: "object", AdditionalProperties: g.kindSchema(fd.MapValue())}
+	case fd.IsList():
+		schema = &Schema{Type: "array", Items: g.kindSchema(fd)}
+	default:
+		schema = g.kindSchema(fd)
+	}
+	if fd.Options().(*descriptorpb.FieldOptions).GetDeprecated() && schema.Ref == "" {
+		schema.Deprecated = true
+	}
+	if schema.Ref == "" || fd.IsList() || fd.IsMap() {
+		g.rules.apply(fd, schema)
+	}
+	return schema
+}
+
+// kindSchema returns the schema of a single value of the field, following the proto3 json mapping
+func (g *generator) kindSchema(fd protoreflect.FieldDescriptor) *Schema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "uint32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return &Schema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		return g.enumSchema(fd.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return g.messageSchema(fd.Message())
+	}
+	return &Schema{}
+}
+
+func (g *generator) enumSchema(ed protoreflect.EnumDescriptor) *Schema {
+	name := string(ed.FullName())
+	if _, ok := g.doc.Components.Schemas[name]; !ok {
+		schema := &Schema{Type: "string"}
+		values := ed.Values()
+		for i := 0; i < values.Len(); i++ {
+			schema.Enum = append(schema.Enum, string(values.Get(i).Name()))
+		}
+		g.doc.Components.Schemas[name] = schema
+	}
+	return ref(name)
+}
+
+func (g *generator) addErrorSchema() {
+	g.doc.Components.Schemas[errorSchema] = &Schema{
+		Type:        "object",
+		Description: "errorx error",
+		Properties: map[string]*Schema{
+			"code":     {Type: "integer", Format: "int32", Description: "http status code"},
+			"reason":   {Type: "string", Description: "reason of the error, e.g. USER_NOT_FOUND"},
+			"message":  {Type: "string"},
+			"metadata": {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+		},
+	}
+}
+
+func (g *generator) fieldName(fd protoreflect.FieldDescriptor) string {
+	if g.opts.protoNames {
+		return string(fd.Name())
+	}
+	return fd.JSONName()
+}
+
+// wellKnown returns the schema of well known types by their json mapping, or nil
+func wellKnown(md protoreflect.MessageDescriptor) *Schema {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return &Schema{Type: "string", Format: "date-time"}
+	case "google.protobuf.Duration":
+		return &Schema{Type: "string", Format: "duration"}
+	case "google.protobuf.FieldMask":
+		return &Schema{Type: "string", Format: "field-mask"}
+	case "google.protobuf.Empty", "google.protobuf.Struct", "google.protobuf.Any":
+		return &Schema{Type: "object"}
+	case "google.protobuf.Value":
+		return &Schema{}
+	case "google.protobuf.ListValue":
+		return &Schema{Type: "array", Items: &Schema{}}
+	case "google.protobuf.BoolValue":
+		return &Schema{Type: "boolean"}
+	case "google.protobuf.StringValue":
+		return &Schema{Type: "string"}
+	case "google.protobuf.BytesValue":
+		return &Schema{Type: "string", Format: "byte"}
+	case "google.protobuf.Int32Value":
+		return &Schema{Type: "integer", Format: "int32"}
+	case "google.protobuf.UInt32Value":
+		return &Schema{Type: "integer", Format: "uint32"}
+	case "google.protobuf.Int64Value":
+		return &Schema{Type: "string", Format: "int64"}
+	case "google.protobuf.UInt64Value":
+		return &Schema{Type: "string", Format: "uint64"}
+	case "google.protobuf.FloatValue":
+		return &Schema{Type: "number", Format: "float"}
+	case "google.protobuf.DoubleValue":
+		return &Schema{Type: "number", Format: "double"}
+	}
+	return nil
+}
+
+func fieldBehaviors(fd protoreflect.FieldDescriptor) []annotations.FieldBehavior {
+	behaviors, _ := proto.GetExtension(fd.Options(), annotations.E_FieldBehavior).([]annotations.FieldBehavior)
+	return behaviors
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// findField finds the field by its dotted path, e.g. message.id
+func findField(md protoreflect.MessageDescriptor, path string) protoreflect.FieldDescriptor {
+	var fd protoreflect.FieldDescriptor
+	for _, name := range strings.Split(path, ".") {
+		if md == nil {
+			return nil
+		}
+		if fd = md.Fields().ByName(protoreflect.Name(strings.TrimSpace(name))); fd == nil {
+			return nil
+		}
+		md = fd.Message()
+	}
+	return fd
+}
+
+// fieldComment returns the comment of the field by its dotted path
+func fieldComment(msg *protogen.Message, path string) string {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		var found *protogen.Field
+		for _, field := range msg.Fields {
+			if string(field.Desc.Name()) == strings.TrimSpace(name) {
+				found = field
+				break
+			}
+		}
+		if found == nil {
+			return ""
+		}
+		if i == len(names)-1 || found.Message == nil {
+			return comment(found.Comments)
+		}
+		msg = found.Message
+	}
+	return ""
+}
+
+func buildPathVars(path string) (res map[string]*string) {
+	if strings.HasSuffix(path, "/") {
+		fmt.Fprintf(os.Stderr, "\u001B[31mWARN\u001B[m: Path %s should not end with \"/\" \n", path)
+	}
+	pattern := regexp.MustCompile(`(?i){([a-z\.0-9_\s]*)=?([^{}]*)}`)
+	matches := pattern.FindAllStringSubmatch(path, -1)
+	res = make(map[string]*string, len(matches))
+	for _, m := range matches {
+		name := strings.TrimSpace(m[1])
+		if len(name) > 1 && len(m[2]) > 0 {
+			res[name] = &m[2]
+		} else {
+			res[name] = nil
+		}
+	}
+	return
+}
+
+// pathVarNames returns the names of buildPathVars in the order they appear
+func pathVarNames(path string) []string {
+	vars := buildPathVars(path)
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.Index(path, names[i]) < strings.Index(path, names[j])
+	})
+	return names
+}
+
+// templatePath turns `{name=messages/*}` into `{name}`
+func templatePath(path string) string {
+	pattern := regexp.MustCompile(`(?i){([a-z\.0-9_\s]*)=?([^{}]*)}`)
+	return pattern.ReplaceAllStringFunc(path, func(s string) string {
+		return "{" + strings.TrimSpace(pattern.FindStringSubmatch(s)[1]) + "}"
+	})
+}
+
+func comment(c protogen.CommentSet) string {
+	return strings.TrimSpace(string(c.Leading))
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,9 @@
+module github.com/sado0823/go-kitx/cmd/protoc-gen-openapi-kitx
+
+go 1.16
+
+require (
+	google.golang.org/genproto v0.0.0-20220519153652-3a47de7e79bd
+	google.golang.org/protobuf v1.28.0
+	gopkg.in/yaml.v3 v3.0.1
+)
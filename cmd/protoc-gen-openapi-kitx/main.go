@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+var release = "v0.0.1"
+
+var (
+	showVersion = flag.Bool("version", false, "print the version and exit")
+	omitempty   = flag.Bool("omitempty", true, "omit if google.api is empty")
+	filename    = flag.String("filename", "openapi.yaml", "name of the generated file, .json for json output")
+	title       = flag.String("title", "", "title of the api, default is the service name")
+	description = flag.String("description", "", "description of the api")
+	apiVersion  = flag.String("api_version", "0.0.1", "version of the api")
+	naming      = flag.String("naming", "json", "naming of fields, json (lowerCamelCase) or proto (snake_case)")
+)
+
+func main() {
+	flag.Parse()
+	if *showVersion {
+		fmt.Printf("protoc-gen-openapi-kitx %v\n", release)
+		return
+	}
+	protogen.Options{
+		ParamFunc: flag.CommandLine.Set,
+	}.Run(func(gen *protogen.Plugin) error {
+		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		if *naming != "json" && *naming != "proto" {
+			return fmt.Errorf("protoc-gen-openapi-kitx: unknown naming %q, should be json or proto", *naming)
+		}
+		return generate(gen, &options{
+			omitempty:   *omitempty,
+			filename:    *filename,
+			title:       *title,
+			description: *description,
+			version:     *apiVersion,
+			protoNames:  *naming == "proto",
+		})
+	})
+}
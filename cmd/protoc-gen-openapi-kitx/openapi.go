@@ -0,0 +1,125 @@
+package main
+
+// the subset of OpenAPI 3.0 written by the generator, fields keep the order of the spec
+
+type (
+	Document struct {
+		OpenAPI    string               `yaml:"openapi" json:"openapi"`
+		Info       Info                 `yaml:"info" json:"info"`
+		Tags       []*Tag               `yaml:"tags,omitempty" json:"tags,omitempty"`
+		Paths      map[string]*PathItem `yaml:"paths" json:"paths"`
+		Components Components           `yaml:"components" json:"components"`
+	}
+
+	Info struct {
+		Title       string `yaml:"title" json:"title"`
+		Description string `yaml:"description,omitempty" json:"description,omitempty"`
+		Version     string `yaml:"version" json:"version"`
+	}
+
+	Tag struct {
+		Name        string `yaml:"name" json:"name"`
+		Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	}
+
+	PathItem struct {
+		Get     *Operation `yaml:"get,omitempty" json:"get,omitempty"`
+		Put     *Operation `yaml:"put,omitempty" json:"put,omitempty"`
+		Post    *Operation `yaml:"post,omitempty" json:"post,omitempty"`
+		Delete  *Operation `yaml:"delete,omitempty" json:"delete,omitempty"`
+		Options *Operation `yaml:"options,omitempty" json:"options,omitempty"`
+		Head    *Operation `yaml:"head,omitempty" json:"head,omitempty"`
+		Patch   *Operation `yaml:"patch,omitempty" json:"patch,omitempty"`
+		Trace   *Operation `yaml:"trace,omitempty" json:"trace,omitempty"`
+	}
+
+	Operation struct {
+		Tags        []string             `yaml:"tags,omitempty" json:"tags,omitempty"`
+		Summary     string               `yaml:"summary,omitempty" json:"summary,omitempty"`
+		Description string               `yaml:"description,omitempty" json:"description,omitempty"`
+		OperationID string               `yaml:"operationId" json:"operationId"`
+		Parameters  []*Parameter         `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+		RequestBody *RequestBody         `yaml:"requestBody,omitempty" json:"requestBody,omitempty"`
+		Responses   map[string]*Response `yaml:"responses" json:"responses"`
+		Deprecated  bool                 `yaml:"deprecated,omitempty" json:"deprecated,omitempty"`
+	}
+
+	Parameter struct {
+		Name        string  `yaml:"name" json:"name"`
+		In          string  `yaml:"in" json:"in"`
+		Description string  `yaml:"description,omitempty" json:"description,omitempty"`
+		Required    bool    `yaml:"required,omitempty" json:"required,omitempty"`
+		Schema      *Schema `yaml:"schema" json:"schema"`
+	}
+
+	RequestBody struct {
+		Required bool                  `yaml:"required,omitempty" json:"required,omitempty"`
+		Content  map[string]*MediaType `yaml:"content" json:"content"`
+	}
+
+	Response struct {
+		Description string                `yaml:"description" json:"description"`
+		Content     map[string]*MediaType `yaml:"content,omitempty" json:"content,omitempty"`
+	}
+
+	MediaType struct {
+		Schema *Schema `yaml:"schema" json:"schema"`
+	}
+
+	Components struct {
+		Schemas map[string]*Schema `yaml:"schemas" json:"schemas"`
+	}
+
+	Schema struct {
+		Ref                  string             `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+		Type                 string             `yaml:"type,omitempty" json:"type,omitempty"`
+		Format               string             `yaml:"format,omitempty" json:"format,omitempty"`
+		Description          string             `yaml:"description,omitempty" json:"description,omitempty"`
+		Properties           map[string]*Schema `yaml:"properties,omitempty" json:"properties,omitempty"`
+		AdditionalProperties *Schema            `yaml:"additionalProperties,omitempty" json:"additionalProperties,omitempty"`
+		Items                *Schema            `yaml:"items,omitempty" json:"items,omitempty"`
+		Required             []string           `yaml:"required,omitempty" json:"required,omitempty"`
+		Enum                 []interface{}      `yaml:"enum,omitempty" json:"enum,omitempty"`
+		Default              interface{}        `yaml:"default,omitempty" json:"default,omitempty"`
+		Minimum              *float64           `yaml:"minimum,omitempty" json:"minimum,omitempty"`
+		ExclusiveMinimum     bool               `yaml:"exclusiveMinimum,omitempty" json:"exclusiveMinimum,omitempty"`
+		Maximum              *float64           `yaml:"maximum,omitempty" json:"maximum,omitempty"`
+		ExclusiveMaximum     bool               `yaml:"exclusiveMaximum,omitempty" json:"exclusiveMaximum,omitempty"`
+		MinLength            *uint64            `yaml:"minLength,omitempty" json:"minLength,omitempty"`
+		MaxLength            *uint64            `yaml:"maxLength,omitempty" json:"maxLength,omitempty"`
+		Pattern              string             `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+		MinItems             *uint64            `yaml:"minItems,omitempty" json:"minItems,omitempty"`
+		MaxItems             *uint64            `yaml:"maxItems,omitempty" json:"maxItems,omitempty"`
+		UniqueItems          bool               `yaml:"uniqueItems,omitempty" json:"uniqueItems,omitempty"`
+		MinProperties        *uint64            `yaml:"minProperties,omitempty" json:"minProperties,omitempty"`
+		MaxProperties        *uint64            `yaml:"maxProperties,omitempty" json:"maxProperties,omitempty"`
+		ReadOnly             bool               `yaml:"readOnly,omitempty" json:"readOnly,omitempty"`
+		Deprecated           bool               `yaml:"deprecated,omitempty" json:"deprecated,omitempty"`
+	}
+)
+
+func (p *PathItem) set(method string, op *Operation) bool {
+	var target **Operation
+	switch method {
+	case "GET":
+		target = &p.Get
+	case "PUT":
+		target = &p.Put
+	case "POST":
+		target = &p.Post
+	case "DELETE":
+		target = &p.Delete
+	case "OPTIONS":
+		target = &p.Options
+	case "HEAD":
+		target = &p.Head
+	case "PATCH":
+		target = &p.Patch
+	case "TRACE":
+		target = &p.Trace
+	default:
+		return false
+	}
+	*target = op
+	return true
+}
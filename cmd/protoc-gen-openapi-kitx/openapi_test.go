@@ -0,0 +1,447 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/pluginpb"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+)
+
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(jsonName(name)),
+		Number:   proto.Int32(number),
+		Label:    optional,
+		Type:     typ.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+func jsonName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.Title(parts[i])
+	}
+	return strings.Join(parts, "")
+}
+
+// validateFile is the subset of protoc-gen-validate's validate.proto used by the tests
+func validateFile() *descriptorpb.FileDescriptorProto {
+	oneof := func(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		f.OneofIndex = proto.Int32(0)
+		return f
+	}
+	list := func(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		f.Label = repeated
+		return f
+	}
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("validate/validate.proto"),
+		Package:    proto.String("validate"),
+		Dependency: []string{"google/protobuf/descriptor.proto"},
+		Syntax:     proto.String("proto2"),
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("github.com/envoyproxy/protoc-gen-validate/validate")},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("FieldRules"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("message", 17, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".validate.MessageRules"),
+					oneof(field("int32", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".validate.Int32Rules")),
+					oneof(field("string", 14, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".validate.StringRules")),
+					oneof(field("repeated", 18, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".validate.RepeatedRules")),
+				},
+				OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("type")}},
+			},
+			{
+				Name: proto.String("Int32Rules"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("const", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+					field("lt", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+					field("lte", 3, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+					field("gt", 4, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+					field("gte", 5, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+					list(field("in", 6, descriptorpb.FieldDescriptorProto_TYPE_INT32, "")),
+				},
+			},
+			{
+				Name: proto.String("StringRules"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("min_len", 2, descriptorpb.FieldDescriptorProto_TYPE_UINT64, ""),
+					field("max_len", 3, descriptorpb.FieldDescriptorProto_TYPE_UINT64, ""),
+					field("pattern", 6, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+					field("email", 12, descriptorpb.FieldDescriptorProto_TYPE_BOOL, ""),
+					list(field("in", 10, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")),
+				},
+			},
+			{
+				Name: proto.String("RepeatedRules"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("min_items", 1, descriptorpb.FieldDescriptorProto_TYPE_UINT64, ""),
+					field("max_items", 2, descriptorpb.FieldDescriptorProto_TYPE_UINT64, ""),
+					field("unique", 3, descriptorpb.FieldDescriptorProto_TYPE_BOOL, ""),
+					field("items", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".validate.FieldRules"),
+				},
+			},
+			{
+				Name: proto.String("MessageRules"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("required", 2, descriptorpb.FieldDescriptorProto_TYPE_BOOL, ""),
+				},
+			},
+		},
+		Extension: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("rules"),
+			Number:   proto.Int32(1071),
+			Label:    optional,
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(".validate.FieldRules"),
+			Extendee: proto.String(".google.protobuf.FieldOptions"),
+		}},
+	}
+}
+
+// ruleSetter sets validate rules on field options by text paths, e.g. string.min_len
+type ruleSetter struct {
+	xt protoreflect.ExtensionType
+}
+
+func newRuleSetter(t *testing.T, file *descriptorpb.FileDescriptorProto) *ruleSetter {
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &ruleSetter{xt: dynamicpb.NewExtensionType(fd.Extensions().Get(0))}
+}
+
+func (s *ruleSetter) set(f *descriptorpb.FieldDescriptorProto, rule func(fr protoreflect.Message)) {
+	fr := s.xt.New().Message()
+	rule(fr)
+	if f.Options == nil {
+		f.Options = &descriptorpb.FieldOptions{}
+	}
+	proto.SetExtension(f.Options, s.xt, fr.Interface())
+}
+
+// sub returns the populated sub message of m named name
+func sub(m protoreflect.Message, name protoreflect.Name) protoreflect.Message {
+	return m.Mutable(m.Descriptor().Fields().ByName(name)).Message()
+}
+
+func setValue(m protoreflect.Message, name protoreflect.Name, v interface{}) {
+	m.Set(m.Descriptor().Fields().ByName(name), protoreflect.ValueOf(v))
+}
+
+func userFile(t *testing.T, validate *descriptorpb.FileDescriptorProto) *descriptorpb.FileDescriptorProto {
+	s := newRuleSetter(t, validate)
+
+	name := field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")
+	s.set(name, func(fr protoreflect.Message) {
+		rules := sub(fr, "string")
+		setValue(rules, "min_len", uint64(2))
+		setValue(rules, "max_len", uint64(16))
+		setValue(rules, "pattern", "^[a-z]+$")
+	})
+	age := field("age", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, "")
+	s.set(age, func(fr protoreflect.Message) {
+		rules := sub(fr, "int32")
+		setValue(rules, "gt", int32(0))
+		setValue(rules, "lte", int32(150))
+	})
+	emails := field("emails", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")
+	emails.Label = repeated
+	s.set(emails, func(fr protoreflect.Message) {
+		rules := sub(fr, "repeated")
+		setValue(rules, "min_items", uint64(1))
+		setValue(rules, "unique", true)
+		setValue(sub(sub(rules, "items"), "string"), "email", true)
+	})
+	profile := field("profile", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".test.Profile")
+	s.set(profile, func(fr protoreflect.Message) {
+		setValue(sub(fr, "message"), "required", true)
+	})
+	id := field("user_id", 5, descriptorpb.FieldDescriptorProto_TYPE_INT64, "")
+	id.Options = &descriptorpb.FieldOptions{}
+	proto.SetExtension(id.Options, annotations.E_FieldBehavior, []annotations.FieldBehavior{annotations.FieldBehavior_OUTPUT_ONLY})
+	status := field("status", 6, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".test.Status")
+	created := field("created_at", 7, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp")
+
+	get := &descriptorpb.MethodOptions{}
+	proto.SetExtension(get, annotations.E_Http, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/users/{user_id}"},
+		AdditionalBindings: []*annotations.HttpRule{
+			{Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=users/*}"}},
+		},
+	})
+	update := &descriptorpb.MethodOptions{}
+	proto.SetExtension(update, annotations.E_Http, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Patch{Patch: "/v1/users/{user.user_id}"},
+		Body:    "user",
+	})
+	watch := &descriptorpb.MethodOptions{}
+	proto.SetExtension(watch, annotations.E_Http, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/users/{user_id}/watch"},
+	})
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/user.proto"),
+		Package: proto.String("test"),
+		Dependency: []string{
+			"validate/validate.proto",
+			"google/api/annotations.proto",
+			"google/api/field_behavior.proto",
+			"google/protobuf/timestamp.proto",
+		},
+		Syntax:  proto.String("proto3"),
+		Options: &descriptorpb.FileOptions{GoPackage: proto.String("example.com/test;test")},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("UNKNOWN"), Number: proto.Int32(0)},
+				{Name: proto.String("ACTIVE"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:  proto.String("Profile"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("bio", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "")},
+			},
+			{
+				Name:  proto.String("User"),
+				Field: []*descriptorpb.FieldDescriptorProto{name, age, emails, profile, id, status, created},
+			},
+			{
+				Name: proto.String("GetUserRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("user_id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+					field("name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+					field("page_size", 3, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+					field("profile", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".test.Profile"),
+				},
+			},
+			{
+				Name: proto.String("UpdateUserRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("user", 1, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".test.User"),
+					field("update_mask", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Users"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("GetUser"), InputType: proto.String(".test.GetUserRequest"), OutputType: proto.String(".test.User"), Options: get},
+				{Name: proto.String("UpdateUser"), InputType: proto.String(".test.UpdateUserRequest"), OutputType: proto.String(".test.User"), Options: update},
+				{Name: proto.String("WatchUser"), InputType: proto.String(".test.GetUserRequest"), OutputType: proto.String(".test.User"), Options: watch, ServerStreaming: proto.Bool(true)},
+				{Name: proto.String("Upload"), InputType: proto.String(".test.User"), OutputType: proto.String(".test.User"), ClientStreaming: proto.Bool(true)},
+				{Name: proto.String("Internal"), InputType: proto.String(".test.User"), OutputType: proto.String(".test.User")},
+			},
+		}},
+	}
+}
+
+func collect(fd protoreflect.FileDescriptor, seen map[string]bool, out *[]*descriptorpb.FileDescriptorProto) {
+	if seen[fd.Path()] {
+		return
+	}
+	seen[fd.Path()] = true
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		collect(imports.Get(i).FileDescriptor, seen, out)
+	}
+	*out = append(*out, protodesc.ToFileDescriptorProto(fd))
+}
+
+func run(t *testing.T, opts *options) *Document {
+	var files []*descriptorpb.FileDescriptorProto
+	seen := make(map[string]bool)
+	collect(annotations.File_google_api_annotations_proto, seen, &files)
+	collect(annotations.File_google_api_field_behavior_proto, seen, &files)
+	collect(timestamppb.File_google_protobuf_timestamp_proto, seen, &files)
+	validate := validateFile()
+	files = append(files, validate, userFile(t, validate))
+
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"test/user.proto"},
+		ProtoFile:      files,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = generate(gen, opts); err != nil {
+		t.Fatal(err)
+	}
+	resp := gen.Response()
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+	if len(resp.File) != 1 || resp.File[0].GetName() != opts.filename {
+		t.Fatalf("unexpected files: %v", resp.File)
+	}
+	doc := new(Document)
+	if err = yaml.Unmarshal([]byte(resp.File[0].GetContent()), doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func paramNames(params []*Parameter, in string) []string {
+	var names []string
+	for _, p := range params {
+		if p.In == in {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+func TestGenerate(t *testing.T) {
+	doc := run(t, &options{omitempty: true, filename: "openapi.yaml", version: "0.0.1"})
+
+	if doc.OpenAPI != "3.0.3" || doc.Info.Title != "Users API" {
+		t.Fatalf("unexpected header: %s %+v", doc.OpenAPI, doc.Info)
+	}
+	if len(doc.Paths) != 4 {
+		t.Fatalf("paths should be 4, got %d", len(doc.Paths))
+	}
+
+	// path and query params
+	get := doc.Paths["/v1/users/{user_id}"].Get
+	if get == nil || get.OperationID != "Users_GetUser" {
+		t.Fatalf("unexpected get operation: %+v", get)
+	}
+	if names := paramNames(get.Parameters, "path"); len(names) != 1 || names[0] != "user_id" || !get.Parameters[0].Required {
+		t.Fatalf("unexpected path params: %v", names)
+	}
+	if get.Parameters[0].Schema.Type != "string" || get.Parameters[0].Schema.Format != "int64" {
+		t.Fatalf("int64 should be a string: %+v", get.Parameters[0].Schema)
+	}
+	query := paramNames(get.Parameters, "query")
+	if want := []string{"name", "pageSize", "profile.bio"}; !equal(query, want) {
+		t.Fatalf("query params should be %v, got %v", want, query)
+	}
+
+	// additional bindings
+	bind := doc.Paths["/v1/{name}"].Get
+	if bind == nil || bind.OperationID != "Users_GetUser1" {
+		t.Fatalf("unexpected additional binding: %+v", bind)
+	}
+
+	// body field
+	update := doc.Paths["/v1/users/{user.user_id}"].Patch
+	if update == nil || update.RequestBody.Content["application/json"].Schema.Ref != "#/components/schemas/test.User" {
+		t.Fatalf("unexpected update operation: %+v", update)
+	}
+	if query := paramNames(update.Parameters, "query"); !equal(query, []string{"updateMask"}) {
+		t.Fatalf("unexpected query params: %v", query)
+	}
+
+	// streaming
+	watch := doc.Paths["/v1/users/{user_id}/watch"].Get
+	if watch.Responses["200"].Content[sseContentType] == nil || watch.Responses["200"].Content[ndjsonContentType] == nil {
+		t.Fatalf("unexpected stream responses: %+v", watch.Responses["200"])
+	}
+
+	// errors
+	if watch.Responses["default"].Content["application/json"].Schema.Ref != "#/components/schemas/"+errorSchema {
+		t.Fatal("default response should be the error")
+	}
+	if status := doc.Components.Schemas[errorSchema]; status == nil || status.Properties["reason"] == nil {
+		t.Fatalf("unexpected error schema: %+v", status)
+	}
+}
+
+func TestGenerate_Schema(t *testing.T) {
+	doc := run(t, &options{omitempty: true, filename: "openapi.yaml"})
+
+	user := doc.Components.Schemas["test.User"]
+	if user == nil {
+		t.Fatal("user schema should exist")
+	}
+	if !equal(user.Required, []string{"profile"}) {
+		t.Fatalf("profile should be required, got %v", user.Required)
+	}
+
+	name := user.Properties["name"]
+	if *name.MinLength != 2 || *name.MaxLength != 16 || name.Pattern != "^[a-z]+$" {
+		t.Fatalf("unexpected name constraints: %+v", name)
+	}
+	age := user.Properties["age"]
+	if *age.Minimum != 0 || !age.ExclusiveMinimum || *age.Maximum != 150 || age.ExclusiveMaximum {
+		t.Fatalf("unexpected age constraints: %+v", age)
+	}
+	emails := user.Properties["emails"]
+	if emails.Type != "array" || *emails.MinItems != 1 || !emails.UniqueItems || emails.Items.Format != "email" {
+		t.Fatalf("unexpected emails constraints: %+v", emails)
+	}
+	if !user.Properties["userId"].ReadOnly {
+		t.Fatal("output only field should be read only")
+	}
+	if user.Properties["status"].Ref != "#/components/schemas/test.Status" {
+		t.Fatalf("unexpected enum: %+v", user.Properties["status"])
+	}
+	if status := doc.Components.Schemas["test.Status"]; len(status.Enum) != 2 || status.Enum[1] != "ACTIVE" {
+		t.Fatalf("unexpected enum schema: %+v", status)
+	}
+	if created := user.Properties["createdAt"]; created.Type != "string" || created.Format != "date-time" {
+		t.Fatalf("timestamp should be date-time: %+v", created)
+	}
+}
+
+func TestGenerate_Options(t *testing.T) {
+	doc := run(t, &options{omitempty: false, filename: "openapi.yaml", protoNames: true, title: "users"})
+
+	if doc.Info.Title != "users" {
+		t.Fatalf("title should be users, got %s", doc.Info.Title)
+	}
+	internal := doc.Paths["/test.Users/Internal"]
+	if internal == nil || internal.Post == nil || internal.Post.RequestBody == nil {
+		t.Fatal("method without http rule should be POST with body when omitempty is false")
+	}
+	if doc.Paths["/test.Users/Upload"] != nil {
+		t.Fatal("client streaming should be skipped")
+	}
+	if doc.Components.Schemas["test.User"].Properties["user_id"] == nil {
+		t.Fatal("proto names should be used")
+	}
+}
+
+func TestTemplatePath(t *testing.T) {
+	if got := templatePath("/v1/{name=messages/*}/{message.id}"); got != "/v1/{name}/{message.id}" {
+		t.Fatalf("unexpected path: %s", got)
+	}
+	names := pathVarNames("/v1/{b}/{a.id=x/*}")
+	if !equal(names, []string{"b", "a.id"}) {
+		t.Fatalf("unexpected order: %v", names)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
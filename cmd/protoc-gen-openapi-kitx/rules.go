@@ -0,0 +1,225 @@
+package main
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// validateRules is the field option of protoc-gen-validate
+const validateRules = protoreflect.FullName("validate.rules")
+
+// rules reads protoc-gen-validate rules, the extension is built from validate.proto sent by protoc,
+// so that any version of it works without depending on its go package
+type rules struct {
+	xt    protoreflect.ExtensionType
+	types *protoregistry.Types
+}
+
+// newRules returns nil if validate.proto is not imported
+func newRules(gen *protogen.Plugin) *rules {
+	for _, f := range gen.Files {
+		xds := f.Desc.Extensions()
+		if xd := xds.ByName(validateRules.Name()); xd != nil && xd.FullName() == validateRules {
+			r := &rules{xt: dynamicpb.NewExtensionType(xd), types: new(protoregistry.Types)}
+			if err := r.types.RegisterExtension(r.xt); err != nil {
+				return nil
+			}
+			return r
+		}
+	}
+	return nil
+}
+
+// of returns the FieldRules of the field, or nil
+func (r *rules) of(fd protoreflect.FieldDescriptor) protoreflect.Message {
+	if r == nil {
+		return nil
+	}
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	if !ok || opts == nil {
+		return nil
+	}
+	// re-parse the options with the extension known
+	data, err := proto.Marshal(opts)
+	if err != nil {
+		return nil
+	}
+	parsed := new(descriptorpb.FieldOptions)
+	if err = (proto.UnmarshalOptions{Resolver: r.types}).Unmarshal(data, parsed); err != nil {
+		return nil
+	}
+	// the extendee is the FieldOptions of protoc, not descriptorpb, proto.GetExtension would refuse it
+	m, xd := parsed.ProtoReflect(), r.xt.TypeDescriptor()
+	if !m.Has(xd) {
+		return nil
+	}
+	return m.Get(xd).Message()
+}
+
+// required reports whether the message field is marked as required
+func (r *rules) required(fd protoreflect.FieldDescriptor) bool {
+	fr := r.of(fd)
+	if fr == nil {
+		return false
+	}
+	msg, ok := get(fr, "message").(protoreflect.Message)
+	return ok && get(msg, "required") == true
+}
+
+// apply sets the constraints of the field to the schema, for repeated fields the repeated rules
+// go to the array and the item rules to its items
+func (r *rules) apply(fd protoreflect.FieldDescriptor, schema *Schema) {
+	fr := r.of(fd)
+	if fr == nil {
+		return
+	}
+	od := fr.Descriptor().Oneofs().ByName("type")
+	if od == nil {
+		return
+	}
+	set := fr.WhichOneof(od)
+	if set == nil {
+		return
+	}
+	typed := fr.Get(set).Message()
+	switch set.Name() {
+	case "repeated":
+		applySize(typed, schema, "min_items", "max_items", &schema.MinItems, &schema.MaxItems)
+		if get(typed, "unique") == true {
+			schema.UniqueItems = true
+		}
+		if items := get(typed, "items"); items != nil && schema.Items != nil {
+			applyTyped(items.(protoreflect.Message), schema.Items)
+		}
+	case "map":
+		applySize(typed, schema, "min_pairs", "max_pairs", &schema.MinProperties, &schema.MaxProperties)
+	default:
+		target := schema
+		if fd.IsList() && schema.Items != nil {
+			target = schema.Items
+		}
+		applyScalar(set.Name(), typed, target)
+	}
+}
+
+// applyTyped applies FieldRules of repeated items
+func applyTyped(fr protoreflect.Message, schema *Schema) {
+	od := fr.Descriptor().Oneofs().ByName("type")
+	if od == nil {
+		return
+	}
+	if set := fr.WhichOneof(od); set != nil {
+		applyScalar(set.Name(), fr.Get(set).Message(), schema)
+	}
+}
+
+func applyScalar(kind protoreflect.Name, typed protoreflect.Message, schema *Schema) {
+	switch kind {
+	case "string", "bytes":
+		applySize(typed, schema, "min_len", "max_len", &schema.MinLength, &schema.MaxLength)
+		if kind == "string" {
+			applySize(typed, schema, "min_bytes", "max_bytes", &schema.MinLength, &schema.MaxLength)
+		}
+		if v, ok := get(typed, "len").(uint64); ok {
+			schema.MinLength, schema.MaxLength = &v, &v
+		}
+		if v, ok := get(typed, "pattern").(string); ok {
+			schema.Pattern = v
+		}
+		for _, format := range []string{"email", "hostname", "ipv4", "ipv6", "uri", "uuid"} {
+			if get(typed, protoreflect.Name(format)) == true {
+				schema.Format = format
+			}
+		}
+		if get(typed, "ip") == true {
+			schema.Format = "ip"
+		}
+	case "enum", "message", "any", "duration", "timestamp":
+		// enum values are listed by the enum schema, the others have no json schema counterpart
+		return
+	case "bool":
+	default:
+		// numbers
+		if v, ok := number(get(typed, "gt")); ok {
+			schema.Minimum, schema.ExclusiveMinimum = &v, true
+		}
+		if v, ok := number(get(typed, "gte")); ok {
+			schema.Minimum, schema.ExclusiveMinimum = &v, false
+		}
+		if v, ok := number(get(typed, "lt")); ok {
+			schema.Maximum, schema.ExclusiveMaximum = &v, true
+		}
+		if v, ok := number(get(typed, "lte")); ok {
+			schema.Maximum, schema.ExclusiveMaximum = &v, false
+		}
+	}
+
+	if v := get(typed, "const"); v != nil {
+		schema.Enum = []interface{}{scalar(v)}
+	}
+	if list, ok := get(typed, "in").(protoreflect.List); ok && list.Len() > 0 {
+		schema.Enum = schema.Enum[:0]
+		for i := 0; i < list.Len(); i++ {
+			schema.Enum = append(schema.Enum, scalar(list.Get(i).Interface()))
+		}
+	}
+}
+
+func applySize(typed protoreflect.Message, schema *Schema, minName, maxName protoreflect.Name, min, max **uint64) {
+	if v, ok := get(typed, minName).(uint64); ok {
+		*min = &v
+	}
+	if v, ok := get(typed, maxName).(uint64); ok {
+		*max = &v
+	}
+}
+
+// get returns the value of the populated field named name, or nil
+func get(m protoreflect.Message, name protoreflect.Name) interface{} {
+	fd := m.Descriptor().Fields().ByName(name)
+	if fd == nil || !m.Has(fd) {
+		return nil
+	}
+	v := m.Get(fd)
+	switch {
+	case fd.IsList():
+		return v.List()
+	case fd.Message() != nil:
+		return v.Message()
+	}
+	return v.Interface()
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		if math.IsInf(n, 0) || math.IsNaN(n) {
+			return 0, false
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+func scalar(v interface{}) interface{} {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
@@ -17,6 +17,7 @@ require (
 	github.com/goccy/go-graphviz v0.1.1
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/mux v1.8.0
+	github.com/gorilla/websocket v1.5.0
 	github.com/pelletier/go-toml/v2 v2.0.5
 	github.com/pkg/errors v0.9.1
 	github.com/spaolacci/murmur3 v1.1.0
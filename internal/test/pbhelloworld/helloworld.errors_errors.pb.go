@@ -36,3 +36,12 @@ func IsContentMissing(err error) bool {
 func ErrorContentMissing(format string, args ...interface{}) *errorx.Error {
 	return errorx.New(400, ErrorReason_CONTENT_MISSING.String(), fmt.Sprintf(format, args...))
 }
+
+// ErrorReasonFromError returns the ErrorReason of err, e.g. an error decoded by kitx http or grpc clients
+func ErrorReasonFromError(err error) (ErrorReason, bool) {
+	if err == nil {
+		return 0, false
+	}
+	v, ok := ErrorReason_value[errorx.FromError(err).Reason]
+	return ErrorReason(v), ok
+}
@@ -78,17 +78,21 @@ type GreeterHTTPClient interface {
 }
 
 type GreeterHTTPClientImpl struct {
-	cc *http.Client
+	cc   *http.Client
+	opts http.MethodCallOptions
 }
 
-func NewGreeterHTTPClient(client *http.Client) GreeterHTTPClient {
-	return &GreeterHTTPClientImpl{client}
+// NewGreeterHTTPClient opts override the defaults from proto options per method,
+// e.g. a http.BodyEncoder of a method
+func NewGreeterHTTPClient(client *http.Client, opts ...http.MethodOption) GreeterHTTPClient {
+	return &GreeterHTTPClientImpl{cc: client, opts: http.NewMethodCallOptions(opts...)}
 }
 
 func (c *GreeterHTTPClientImpl) AddUser(ctx context.Context, in *AddUserRequest, opts ...http.CallOption) (*HelloReply, error) {
 	var out HelloReply
 	pattern := "/add/user"
 	path := binding.EncodeURL(pattern, in, false)
+	opts = append(c.opts.Get(OperationGreeterAddUser), opts...)
 	opts = append(opts, http.Operation(OperationGreeterAddUser))
 	opts = append(opts, http.PathTemplate(pattern))
 	err := c.cc.Invoke(ctx, "POST", path, in, &out, opts...)
@@ -102,6 +106,10 @@ func (c *GreeterHTTPClientImpl) SayHello(ctx context.Context, in *HelloRequest,
 	var out HelloReply
 	pattern := "/helloworld/{name}"
 	path := binding.EncodeURL(pattern, in, true)
+	defaults := []http.CallOption{
+		http.Idempotent(true),
+	}
+	opts = append(append(defaults, c.opts.Get(OperationGreeterSayHello)...), opts...)
 	opts = append(opts, http.Operation(OperationGreeterSayHello))
 	opts = append(opts, http.PathTemplate(pattern))
 	err := c.cc.Invoke(ctx, "GET", path, nil, &out, opts...)
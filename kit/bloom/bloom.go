@@ -2,16 +2,15 @@ package bloom
 
 import (
 	"context"
+	"sync/atomic"
 )
 
 type (
 	// Filter is a bloom filter
 	Filter struct {
-
-		// todo counter
-		//total int64
-		//hit   int64
-		//miss  int64
+		total uint64
+		hit   uint64
+		miss  uint64
 
 		Provider
 	}
@@ -20,8 +19,109 @@ type (
 		Add(ctx context.Context, data []byte) error
 		Exists(ctx context.Context, data []byte) (bool, error)
 	}
+
+	// BatchProvider is a Provider which handles many items at once, e.g. in a redis pipeline
+	BatchProvider interface {
+		Provider
+		AddMany(ctx context.Context, data [][]byte) error
+		ExistsMany(ctx context.Context, data [][]byte) ([]bool, error)
+	}
+
+	// Estimator is a Provider which knows how full it is
+	Estimator interface {
+		// Estimate returns the ratio of set bits and the false positive rate derived from it
+		Estimate(ctx context.Context) (fillRatio, fpRate float64, err error)
+	}
+
+	// Stats of a Filter, hits are items found by Exists, misses are not found,
+	// a filter with a high estimated false positive rate should be rebuilt
+	Stats struct {
+		Total             uint64
+		Hits              uint64
+		Misses            uint64
+		FillRatio         float64
+		FalsePositiveRate float64
+	}
 )
 
 func NewWithProvider(provider Provider) *Filter {
 	return &Filter{Provider: provider}
 }
+
+// Exists check data and count hit or miss
+func (f *Filter) Exists(ctx context.Context, data []byte) (bool, error) {
+	ok, err := f.Provider.Exists(ctx, data)
+	if err != nil {
+		return false, err
+	}
+
+	f.count(ok)
+	return ok, nil
+}
+
+// AddMany add data in a batch if Provider is a BatchProvider, otherwise one by one
+func (f *Filter) AddMany(ctx context.Context, data [][]byte) error {
+	if batch, ok := f.Provider.(BatchProvider); ok {
+		return batch.AddMany(ctx, data)
+	}
+
+	for _, v := range data {
+		if err := f.Provider.Add(ctx, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExistsMany check data in a batch if Provider is a BatchProvider, otherwise one by one
+func (f *Filter) ExistsMany(ctx context.Context, data [][]byte) ([]bool, error) {
+	var (
+		result []bool
+		err    error
+	)
+	if batch, ok := f.Provider.(BatchProvider); ok {
+		result, err = batch.ExistsMany(ctx, data)
+	} else {
+		result = make([]bool, len(data))
+		for i, v := range data {
+			if result[i], err = f.Provider.Exists(ctx, v); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ok := range result {
+		f.count(ok)
+	}
+	return result, nil
+}
+
+// Stats returns counters of Filter, FillRatio and FalsePositiveRate are filled if Provider is an Estimator
+func (f *Filter) Stats(ctx context.Context) (Stats, error) {
+	stats := Stats{
+		Total:  atomic.LoadUint64(&f.total),
+		Hits:   atomic.LoadUint64(&f.hit),
+		Misses: atomic.LoadUint64(&f.miss),
+	}
+
+	if estimator, ok := f.Provider.(Estimator); ok {
+		fillRatio, fpRate, err := estimator.Estimate(ctx)
+		if err != nil {
+			return stats, err
+		}
+		stats.FillRatio, stats.FalsePositiveRate = fillRatio, fpRate
+	}
+	return stats, nil
+}
+
+func (f *Filter) count(hit bool) {
+	atomic.AddUint64(&f.total, 1)
+	if hit {
+		atomic.AddUint64(&f.hit, 1)
+	} else {
+		atomic.AddUint64(&f.miss, 1)
+	}
+}
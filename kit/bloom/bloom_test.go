@@ -0,0 +1,68 @@
+package bloom
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_Stats(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		filter = NewWithProvider(NewLocalProvider(1000, 0.01))
+	)
+
+	stats, err := filter.Stats(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, Stats{}, stats)
+
+	var data [][]byte
+	for i := 0; i < 1000; i++ {
+		data = append(data, []byte(strconv.Itoa(i)))
+	}
+	assert.Nil(t, filter.AddMany(ctx, data))
+
+	ok, err := filter.Exists(ctx, []byte("1"))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	result, err := filter.ExistsMany(ctx, [][]byte{[]byte("2"), []byte("not exists")})
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{true, false}, result)
+
+	stats, err = filter.Stats(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), stats.Total)
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	// a full filter has half bits set, and fp rate as sized
+	assert.InDelta(t, 0.5, stats.FillRatio, 0.05)
+	assert.InDelta(t, 0.01, stats.FalsePositiveRate, 0.003)
+}
+
+func TestEstimate(t *testing.T) {
+	ctx := context.Background()
+	providers := []Provider{
+		NewLocalProvider(1000, 0.01),
+		NewCountingProvider(1000, 0.01),
+		NewScalableProvider(100, 0.01),
+	}
+
+	for _, provider := range providers {
+		estimator := provider.(Estimator)
+		fillRatio, fpRate, err := estimator.Estimate(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, float64(0), fillRatio)
+		assert.Equal(t, float64(0), fpRate)
+
+		for i := 0; i < 1000; i++ {
+			assert.Nil(t, provider.Add(ctx, []byte(strconv.Itoa(i))))
+		}
+		fillRatio, fpRate, err = estimator.Estimate(ctx)
+		assert.Nil(t, err)
+		assert.True(t, fillRatio > 0.3 && fillRatio < 0.6, fillRatio)
+		assert.True(t, fpRate > 0 && fpRate < 0.02, fpRate)
+	}
+}
@@ -0,0 +1,149 @@
+package bloom
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+)
+
+type (
+	// CountingProvider is an in-process counting bloom filter, each location is a counter
+	// instead of a bit, so items can be removed. counters saturate at 255 and never go down then
+	CountingProvider struct {
+		lock     sync.RWMutex
+		counters []uint8
+		m        uint64
+		k        uint64
+		count    uint64
+	}
+)
+
+var (
+	_ Provider    = (*CountingProvider)(nil)
+	_ Snapshotter = (*CountingProvider)(nil)
+	_ Estimator   = (*CountingProvider)(nil)
+)
+
+// NewCountingProvider create a CountingProvider sized for expected items with the false positive rate
+func NewCountingProvider(expected uint, fpRate float64) *CountingProvider {
+	m, k := optimal(expected, fpRate)
+	return &CountingProvider{
+		counters: make([]uint8, m),
+		m:        m,
+		k:        k,
+	}
+}
+
+// Add implement Provider interface
+func (c *CountingProvider) Add(ctx context.Context, data []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, location := range locations(data, c.k, c.m) {
+		if c.counters[location] < math.MaxUint8 {
+			c.counters[location]++
+		}
+	}
+	c.count++
+	return nil
+}
+
+// Exists implement Provider interface
+func (c *CountingProvider) Exists(ctx context.Context, data []byte) (bool, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.exists(locations(data, c.k, c.m)), nil
+}
+
+// Remove an added item, it's a noop if the item doesn't exist.
+// removing an item which is never added may remove others by false positive
+func (c *CountingProvider) Remove(ctx context.Context, data []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	l := locations(data, c.k, c.m)
+	if !c.exists(l) {
+		return nil
+	}
+
+	for _, location := range l {
+		if c.counters[location] < math.MaxUint8 {
+			c.counters[location]--
+		}
+	}
+	c.count--
+	return nil
+}
+
+// Count returns how many items are added and not removed
+func (c *CountingProvider) Count() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.count
+}
+
+// Estimate implement Estimator interface, a location is set if its counter is not zero
+func (c *CountingProvider) Estimate(ctx context.Context) (fillRatio, fpRate float64, err error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	var set int
+	for _, counter := range c.counters {
+		if counter > 0 {
+			set++
+		}
+	}
+	fillRatio = float64(set) / float64(c.m)
+	return fillRatio, math.Pow(fillRatio, float64(c.k)), nil
+}
+
+// Snapshot implement Snapshotter interface
+func (c *CountingProvider) Snapshot(w io.Writer) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if _, err := w.Write([]byte{snapshotCounting}); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, []uint64{c.m, c.k, c.count}); err != nil {
+		return err
+	}
+	_, err := w.Write(c.counters)
+	return err
+}
+
+// Restore implement Snapshotter interface
+func (c *CountingProvider) Restore(r io.Reader) error {
+	if err := readKind(r, snapshotCounting); err != nil {
+		return err
+	}
+
+	header := make([]uint64, 3)
+	if err := binary.Read(r, binary.BigEndian, header); err != nil {
+		return err
+	}
+	if header[0] == 0 || header[1] == 0 {
+		return ErrInvalidSnapshot
+	}
+
+	counters := make([]uint8, header[0])
+	if _, err := io.ReadFull(r, counters); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.counters, c.m, c.k, c.count = counters, header[0], header[1], header[2]
+	c.lock.Unlock()
+	return nil
+}
+
+func (c *CountingProvider) exists(l []uint64) bool {
+	for _, location := range l {
+		if c.counters[location] == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package bloom
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountingProvider(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		provider = NewCountingProvider(1000, 0.01)
+	)
+
+	for i := 0; i < 1000; i++ {
+		assert.Nil(t, provider.Add(ctx, []byte(strconv.Itoa(i))))
+	}
+	for i := 0; i < 500; i++ {
+		assert.Nil(t, provider.Remove(ctx, []byte(strconv.Itoa(i))))
+	}
+	assert.Equal(t, uint64(500), provider.Count())
+
+	var removed int
+	for i := 0; i < 500; i++ {
+		if ok, _ := provider.Exists(ctx, []byte(strconv.Itoa(i))); !ok {
+			removed++
+		}
+	}
+	assert.True(t, removed > 490, removed)
+
+	for i := 500; i < 1000; i++ {
+		ok, err := provider.Exists(ctx, []byte(strconv.Itoa(i)))
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+
+	// remove what doesn't exist is a noop
+	assert.Nil(t, provider.Remove(ctx, []byte("not exists")))
+	assert.Equal(t, uint64(500), provider.Count())
+}
+
+func TestCountingProvider_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	provider := NewCountingProvider(100, 0.01)
+	assert.Nil(t, provider.Add(ctx, []byte("foo")))
+	assert.Nil(t, provider.Add(ctx, []byte("bar")))
+
+	buf := new(bytes.Buffer)
+	assert.Nil(t, provider.Snapshot(buf))
+
+	restored := NewCountingProvider(1, 0.5)
+	assert.Nil(t, restored.Restore(buf))
+	assert.Nil(t, restored.Remove(ctx, []byte("foo")))
+	ok, _ := restored.Exists(ctx, []byte("foo"))
+	assert.False(t, ok)
+	ok, _ = restored.Exists(ctx, []byte("bar"))
+	assert.True(t, ok)
+}
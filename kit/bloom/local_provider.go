@@ -0,0 +1,192 @@
+package bloom
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/bits"
+	"sync"
+
+	"github.com/spaolacci/murmur3"
+)
+
+const (
+	snapshotLocal    = 'L'
+	snapshotScalable = 'S'
+	snapshotCounting = 'C'
+)
+
+var ErrInvalidSnapshot = errors.New("invalid bloom snapshot")
+
+type (
+	// Snapshotter is a Provider which can be persisted and restored, e.g. across restarts
+	Snapshotter interface {
+		Snapshot(w io.Writer) error
+		Restore(r io.Reader) error
+	}
+
+	// LocalProvider is an in-process bitset Provider
+	LocalProvider struct {
+		lock  sync.RWMutex
+		bits  []uint64
+		m     uint64
+		k     uint64
+		count uint64
+	}
+)
+
+var (
+	_ Provider    = (*LocalProvider)(nil)
+	_ Snapshotter = (*LocalProvider)(nil)
+	_ Estimator   = (*LocalProvider)(nil)
+)
+
+// NewLocalProvider create a LocalProvider sized for expected items with the false positive rate
+func NewLocalProvider(expected uint, fpRate float64) *LocalProvider {
+	m, k := optimal(expected, fpRate)
+	return &LocalProvider{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// Add implement Provider interface
+func (l *LocalProvider) Add(ctx context.Context, data []byte) error {
+	l.lock.Lock()
+	l.add(data)
+	l.lock.Unlock()
+	return nil
+}
+
+// Exists implement Provider interface
+func (l *LocalProvider) Exists(ctx context.Context, data []byte) (bool, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.exists(data), nil
+}
+
+// Count returns how many items are added
+func (l *LocalProvider) Count() uint64 {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.count
+}
+
+// Estimate implement Estimator interface
+func (l *LocalProvider) Estimate(ctx context.Context) (fillRatio, fpRate float64, err error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	fillRatio = l.fillRatio()
+	return fillRatio, math.Pow(fillRatio, float64(l.k)), nil
+}
+
+// Snapshot implement Snapshotter interface
+func (l *LocalProvider) Snapshot(w io.Writer) error {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if _, err := w.Write([]byte{snapshotLocal}); err != nil {
+		return err
+	}
+	return l.writeTo(w)
+}
+
+// Restore implement Snapshotter interface
+func (l *LocalProvider) Restore(r io.Reader) error {
+	if err := readKind(r, snapshotLocal); err != nil {
+		return err
+	}
+
+	restored := new(LocalProvider)
+	if err := restored.readFrom(r); err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	l.bits, l.m, l.k, l.count = restored.bits, restored.m, restored.k, restored.count
+	l.lock.Unlock()
+	return nil
+}
+
+func (l *LocalProvider) add(data []byte) {
+	for _, location := range locations(data, l.k, l.m) {
+		l.bits[location>>6] |= 1 << (location & 63)
+	}
+	l.count++
+}
+
+func (l *LocalProvider) exists(data []byte) bool {
+	for _, location := range locations(data, l.k, l.m) {
+		if l.bits[location>>6]&(1<<(location&63)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *LocalProvider) fillRatio() float64 {
+	var set int
+	for _, word := range l.bits {
+		set += bits.OnesCount64(word)
+	}
+	return float64(set) / float64(l.m)
+}
+
+func (l *LocalProvider) writeTo(w io.Writer) error {
+	if err := binary.Write(w, binary.BigEndian, []uint64{l.m, l.k, l.count}); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.BigEndian, l.bits)
+}
+
+func (l *LocalProvider) readFrom(r io.Reader) error {
+	header := make([]uint64, 3)
+	if err := binary.Read(r, binary.BigEndian, header); err != nil {
+		return err
+	}
+	if header[0] == 0 || header[1] == 0 {
+		return ErrInvalidSnapshot
+	}
+
+	l.m, l.k, l.count = header[0], header[1], header[2]
+	l.bits = make([]uint64, (l.m+63)/64)
+	return binary.Read(r, binary.BigEndian, l.bits)
+}
+
+// optimal returns bits count m and hash count k,
+// m = -n*ln(p) / ln(2)^2, k = m/n * ln(2)
+func optimal(expected uint, fpRate float64) (m, k uint64) {
+	if expected == 0 || fpRate <= 0 || fpRate >= 1 {
+		panic("invalid bloom param")
+	}
+
+	n := float64(expected)
+	m = uint64(math.Ceil(-n * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k = uint64(math.Max(1, math.Round(float64(m)/n*math.Ln2)))
+	return m, k
+}
+
+// locations returns k locations in [0, m) by double hashing, g(i) = h1 + i*h2
+func locations(data []byte, k, m uint64) []uint64 {
+	h1, h2 := murmur3.Sum128(data)
+	l := make([]uint64, k)
+	for i := uint64(0); i < k; i++ {
+		l[i] = (h1 + i*h2) % m
+	}
+	return l
+}
+
+func readKind(r io.Reader, kind byte) error {
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return err
+	}
+	if b[0] != kind {
+		return ErrInvalidSnapshot
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package bloom
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptimal(t *testing.T) {
+	m, k := optimal(1000, 0.01)
+	assert.Equal(t, uint64(9586), m)
+	assert.Equal(t, uint64(7), k)
+
+	assert.Panics(t, func() {
+		optimal(0, 0.01)
+	})
+	assert.Panics(t, func() {
+		optimal(1000, 1)
+	})
+}
+
+func TestLocalProvider(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		expected = 10000
+		provider = NewLocalProvider(uint(expected), 0.01)
+	)
+
+	for i := 0; i < expected; i++ {
+		assert.Nil(t, provider.Add(ctx, []byte(strconv.Itoa(i))))
+	}
+	assert.Equal(t, uint64(expected), provider.Count())
+
+	for i := 0; i < expected; i++ {
+		ok, err := provider.Exists(ctx, []byte(strconv.Itoa(i)))
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+
+	var falsePositive int
+	for i := expected; i < expected*2; i++ {
+		if ok, _ := provider.Exists(ctx, []byte(strconv.Itoa(i))); ok {
+			falsePositive++
+		}
+	}
+	assert.True(t, float64(falsePositive)/float64(expected) < 0.02, falsePositive)
+}
+
+func TestLocalProvider_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	provider := NewLocalProvider(100, 0.01)
+	assert.Nil(t, provider.Add(ctx, []byte("foo")))
+
+	buf := new(bytes.Buffer)
+	assert.Nil(t, provider.Snapshot(buf))
+
+	restored := NewLocalProvider(1, 0.5)
+	assert.Nil(t, restored.Restore(bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, uint64(1), restored.Count())
+	ok, _ := restored.Exists(ctx, []byte("foo"))
+	assert.True(t, ok)
+	ok, _ = restored.Exists(ctx, []byte("bar"))
+	assert.False(t, ok)
+
+	// snapshot of another kind
+	assert.ErrorIs(t, NewCountingProvider(1, 0.5).Restore(bytes.NewReader(buf.Bytes())), ErrInvalidSnapshot)
+	assert.NotNil(t, restored.Restore(bytes.NewReader(buf.Bytes()[:10])))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/sado0823/go-kitx/kit/store/redis"
@@ -12,7 +13,9 @@ import (
 
 const (
 	// for detail, see http://pages.cs.wisc.edu/~cao/papers/summary-cache/node8.html
-	maps      = 14
+	maps = 14
+	// keyPrefix versions the bit layout, filters of an older layout are never read with a newer one
+	keyPrefix = "bloom:v2:"
 	setScript = `
 for _, offset in ipairs(ARGV) do
 	redis.call("setbit", KEYS[1], offset, 1)
@@ -30,14 +33,21 @@ return true
 
 var ErrTooLargeOffset = errors.New("too large offset")
 
+var (
+	_ BatchProvider = (*rdsProvider)(nil)
+	_ Estimator     = (*rdsProvider)(nil)
+)
+
 type rdsProvider struct {
 	store *redis.Redis
 	key   string
 	bits  uint
 }
 
+// NewRedisProvider returns a provider storing bits at keyPrefix+key, filters written before v2
+// mapped every data into the first 14 bits, they are left as they were and need to be rebuilt
 func NewRedisProvider(addr string, key string, bits uint) Provider {
-	return &rdsProvider{store: redis.New(addr), key: key, bits: bits}
+	return &rdsProvider{store: redis.New(addr), key: keyPrefix + key, bits: bits}
 }
 
 // Add implement Provider interface
@@ -52,12 +62,75 @@ func (r *rdsProvider) Exists(ctx context.Context, data []byte) (bool, error) {
 	return r.check(ctx, location)
 }
 
-// getBitLocation return data hash to bit location
+// AddMany implement BatchProvider interface, all bits are set in one pipeline
+func (r *rdsProvider) AddMany(ctx context.Context, data [][]byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	_, err := r.store.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, v := range data {
+			for _, offset := range r.getBitLocation(v) {
+				pipe.SetBit(ctx, r.key, int64(offset), 1)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// ExistsMany implement BatchProvider interface, all bits are got in one pipeline
+func (r *rdsProvider) ExistsMany(ctx context.Context, data [][]byte) ([]bool, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	cmds, err := r.store.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, v := range data {
+			for _, offset := range r.getBitLocation(v) {
+				pipe.GetBit(ctx, r.key, int64(offset))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]bool, len(data))
+	for i := range data {
+		result[i] = true
+		for _, cmd := range cmds[i*maps : (i+1)*maps] {
+			bit, err := cmd.(*redis.IntCmd).Result()
+			if err != nil {
+				return nil, err
+			}
+			if bit == 0 {
+				result[i] = false
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// Estimate implement Estimator interface
+func (r *rdsProvider) Estimate(ctx context.Context) (fillRatio, fpRate float64, err error) {
+	set, err := r.store.BitCount(ctx, r.key)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fillRatio = float64(set) / float64(r.bits)
+	return fillRatio, math.Pow(fillRatio, maps), nil
+}
+
+// getBitLocation return data hash to bit location, changing it needs a new keyPrefix
 func (r *rdsProvider) getBitLocation(data []byte) []uint {
 	l := make([]uint, maps)
 	for i := 0; i < maps; i++ {
 		hashV := r.hash(append(data, byte(i)))
-		l[i] = uint(hashV % uint64(maps))
+		l[i] = uint(hashV % uint64(r.bits))
 	}
 	return l
 }
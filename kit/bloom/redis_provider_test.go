@@ -2,6 +2,7 @@ package bloom
 
 import (
 	"context"
+	"strconv"
 	"testing"
 	"time"
 
@@ -73,3 +74,46 @@ func TestRedisBitSet_Add(t *testing.T) {
 	assert.Nil(t, err)
 	assert.True(t, ok)
 }
+
+func TestRedisBitSet_Many(t *testing.T) {
+	addr, clean, err := createRedis()
+	assert.Nil(t, err)
+	defer clean()
+
+	ctx := context.Background()
+	filter := NewWithProvider(NewRedisProvider(addr, "test_key", 10240))
+
+	var data [][]byte
+	for i := 0; i < 100; i++ {
+		data = append(data, []byte(strconv.Itoa(i)))
+	}
+	assert.Nil(t, filter.AddMany(ctx, data))
+	assert.Nil(t, filter.AddMany(ctx, nil))
+
+	result, err := filter.ExistsMany(ctx, append(data, []byte("not exists")))
+	assert.Nil(t, err)
+	assert.Len(t, result, 101)
+	for i := 0; i < 100; i++ {
+		assert.True(t, result[i])
+	}
+	assert.False(t, result[100])
+
+	stats, err := filter.Stats(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(101), stats.Total)
+	assert.Equal(t, uint64(100), stats.Hits)
+	assert.True(t, stats.FillRatio > 0 && stats.FillRatio <= float64(100*maps)/10240, stats.FillRatio)
+	assert.True(t, stats.FalsePositiveRate > 0 && stats.FalsePositiveRate < 0.001, stats.FalsePositiveRate)
+}
+
+func TestRedisBitSet_BitLocation(t *testing.T) {
+	// the layout of stored filters, it must not change without a new keyPrefix
+	filter := &rdsProvider{key: "test_key", bits: 1024}
+	assert.Equal(t, []uint{377, 115, 996, 319, 947, 974, 652, 527, 669, 40, 243, 233, 2, 231}, filter.getBitLocation([]byte("kitx")))
+
+
+	addr, clean, err := createRedis()
+	assert.Nil(t, err)
+	defer clean()
+	assert.Equal(t, "bloom:v2:test_key", NewRedisProvider(addr, "test_key", 1024).(*rdsProvider).key)
+}
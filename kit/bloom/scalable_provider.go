@@ -0,0 +1,200 @@
+package bloom
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+)
+
+const (
+	scalableGrowth     = 2
+	scalableTightening = 0.8
+)
+
+type (
+	ScalableOptionFn func(*ScalableProvider)
+
+	// ScalableProvider grows by stacking LocalProviders, when the last one is full,
+	// a larger one with a tighter false positive rate is added, so the overall rate stays bounded
+	// no matter how many items are added.
+	// see https://gsd.di.uminho.pt/members/cbm/ps/dbloom.pdf
+	ScalableProvider struct {
+		lock       sync.RWMutex
+		filters    []*LocalProvider
+		capacities []uint64
+		fpRate     float64
+		growth     uint
+		tightening float64
+	}
+)
+
+var (
+	_ Provider    = (*ScalableProvider)(nil)
+	_ Snapshotter = (*ScalableProvider)(nil)
+	_ Estimator   = (*ScalableProvider)(nil)
+)
+
+// WithScalableGrowth set how many times the next filter is larger than the previous one
+func WithScalableGrowth(growth uint) ScalableOptionFn {
+	return func(s *ScalableProvider) {
+		if growth > 0 {
+			s.growth = growth
+		}
+	}
+}
+
+// WithScalableTightening set the ratio of false positive rate of the next filter to the previous one
+func WithScalableTightening(ratio float64) ScalableOptionFn {
+	return func(s *ScalableProvider) {
+		if ratio > 0 && ratio < 1 {
+			s.tightening = ratio
+		}
+	}
+}
+
+// NewScalableProvider create a ScalableProvider, the first filter holds initial items with fpRate
+func NewScalableProvider(initial uint, fpRate float64, options ...ScalableOptionFn) *ScalableProvider {
+	s := &ScalableProvider{
+		fpRate:     fpRate,
+		growth:     scalableGrowth,
+		tightening: scalableTightening,
+	}
+	for _, option := range options {
+		option(s)
+	}
+
+	// the sum of rates is fpRate/(1-tightening), scale down the first one to bound it to fpRate
+	s.grow(initial, fpRate*(1-s.tightening))
+	return s
+}
+
+// Add implement Provider interface
+func (s *ScalableProvider) Add(ctx context.Context, data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.exists(data) {
+		return nil
+	}
+
+	last := len(s.filters) - 1
+	if s.filters[last].count >= s.capacities[last] {
+		s.grow(uint(s.capacities[last])*s.growth, s.rateOf(last)*s.tightening)
+		last++
+	}
+	s.filters[last].add(data)
+	return nil
+}
+
+// Exists implement Provider interface
+func (s *ScalableProvider) Exists(ctx context.Context, data []byte) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.exists(data), nil
+}
+
+// Len returns how many filters are stacked
+func (s *ScalableProvider) Len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.filters)
+}
+
+// Estimate implement Estimator interface, fill ratio is of all stacked filters,
+// an item is a false positive if any filter reports it
+func (s *ScalableProvider) Estimate(ctx context.Context) (fillRatio, fpRate float64, err error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var (
+		set, total float64
+		negative   = 1.0
+	)
+	for _, filter := range s.filters {
+		ratio := filter.fillRatio()
+		set += ratio * float64(filter.m)
+		total += float64(filter.m)
+		negative *= 1 - math.Pow(ratio, float64(filter.k))
+	}
+	return set / total, 1 - negative, nil
+}
+
+// Snapshot implement Snapshotter interface
+func (s *ScalableProvider) Snapshot(w io.Writer) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if _, err := w.Write([]byte{snapshotScalable}); err != nil {
+		return err
+	}
+	header := []uint64{uint64(s.growth), math.Float64bits(s.tightening), math.Float64bits(s.fpRate), uint64(len(s.filters))}
+	if err := binary.Write(w, binary.BigEndian, header); err != nil {
+		return err
+	}
+	for i, filter := range s.filters {
+		if err := binary.Write(w, binary.BigEndian, s.capacities[i]); err != nil {
+			return err
+		}
+		if err := filter.writeTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore implement Snapshotter interface
+func (s *ScalableProvider) Restore(r io.Reader) error {
+	if err := readKind(r, snapshotScalable); err != nil {
+		return err
+	}
+
+	header := make([]uint64, 4)
+	if err := binary.Read(r, binary.BigEndian, header); err != nil {
+		return err
+	}
+	if header[0] == 0 || header[3] == 0 {
+		return ErrInvalidSnapshot
+	}
+
+	var (
+		filters    = make([]*LocalProvider, header[3])
+		capacities = make([]uint64, header[3])
+	)
+	for i := range filters {
+		if err := binary.Read(r, binary.BigEndian, &capacities[i]); err != nil {
+			return err
+		}
+		filters[i] = new(LocalProvider)
+		if err := filters[i].readFrom(r); err != nil {
+			return err
+		}
+	}
+
+	s.lock.Lock()
+	s.growth, s.tightening, s.fpRate = uint(header[0]), math.Float64frombits(header[1]), math.Float64frombits(header[2])
+	s.filters, s.capacities = filters, capacities
+	s.lock.Unlock()
+	return nil
+}
+
+func (s *ScalableProvider) grow(capacity uint, fpRate float64) {
+	s.filters = append(s.filters, NewLocalProvider(capacity, fpRate))
+	s.capacities = append(s.capacities, uint64(capacity))
+}
+
+// rateOf returns the false positive rate the i-th filter is sized with
+func (s *ScalableProvider) rateOf(i int) float64 {
+	return s.fpRate * (1 - s.tightening) * math.Pow(s.tightening, float64(i))
+}
+
+func (s *ScalableProvider) exists(data []byte) bool {
+	// recent filters hold most items, check them first
+	for i := len(s.filters) - 1; i >= 0; i-- {
+		if s.filters[i].exists(data) {
+			return true
+		}
+	}
+	return false
+}
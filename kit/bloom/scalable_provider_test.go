@@ -0,0 +1,64 @@
+package bloom
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScalableProvider(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		total    = 10000
+		provider = NewScalableProvider(100, 0.01)
+	)
+
+	for i := 0; i < total; i++ {
+		assert.Nil(t, provider.Add(ctx, []byte(strconv.Itoa(i))))
+	}
+	// 100 + 200 + ... + 6400 < 10000 <= 12700
+	assert.Equal(t, 7, provider.Len())
+
+	for i := 0; i < total; i++ {
+		ok, err := provider.Exists(ctx, []byte(strconv.Itoa(i)))
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+
+	var falsePositive int
+	for i := total; i < total*2; i++ {
+		if ok, _ := provider.Exists(ctx, []byte(strconv.Itoa(i))); ok {
+			falsePositive++
+		}
+	}
+	assert.True(t, float64(falsePositive)/float64(total) < 0.02, falsePositive)
+}
+
+func TestScalableProvider_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	provider := NewScalableProvider(10, 0.01, WithScalableGrowth(4), WithScalableTightening(0.5))
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, provider.Add(ctx, []byte(strconv.Itoa(i))))
+	}
+
+	buf := new(bytes.Buffer)
+	assert.Nil(t, provider.Snapshot(buf))
+
+	restored := NewScalableProvider(10, 0.1)
+	assert.Nil(t, restored.Restore(buf))
+	assert.Equal(t, provider.Len(), restored.Len())
+	for i := 0; i < 100; i++ {
+		ok, _ := restored.Exists(ctx, []byte(strconv.Itoa(i)))
+		assert.True(t, ok)
+	}
+
+	// keep growing as the original one
+	for i := 100; i < 1000; i++ {
+		assert.Nil(t, provider.Add(ctx, []byte(strconv.Itoa(i))))
+		assert.Nil(t, restored.Add(ctx, []byte(strconv.Itoa(i))))
+	}
+	assert.Equal(t, provider.Len(), restored.Len())
+}
@@ -0,0 +1,296 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/log"
+	rollingwindow "github.com/sado0823/go-kitx/pkg/rollingwindow/v2"
+)
+
+var (
+	ErrClassicBreakOn         = errors.New("classic breaker is open")
+	ErrClassicTooManyRequests = errors.New("classic breaker is half-open and probe quota is used up")
+)
+
+type (
+	ClassicOptionFn func(*classicOption)
+
+	classicOption struct {
+		// trip when consecutive failures reach it, 0 means disabled
+		consecutiveFailures int64
+		// trip when failures/total in window reach it, 0 means disabled
+		failureRatio float64
+		// failure ratio only counts when requests in window reach it
+		minRequests int64
+		windowTime  time.Duration
+		bucketCount int
+		openTimeout time.Duration
+		// requests allowed in half-open state, all of them succeed then closed
+		halfOpenQuota int64
+	}
+
+	classic struct {
+		name   string
+		option *classicOption
+		lock   sync.Mutex
+		rw     *rollingwindow.RollingWindow
+
+		state       State
+		consecutive int64
+		openAt      time.Time
+		probes      int64
+		successes   int64
+
+		onStateChange func(from, to State)
+	}
+)
+
+// WithClassicConsecutiveFailures trip the breaker when n requests failed in a row
+func WithClassicConsecutiveFailures(n int) ClassicOptionFn {
+	return func(option *classicOption) {
+		option.consecutiveFailures = int64(n)
+	}
+}
+
+// WithClassicFailureRatio trip the breaker when failure ratio in window reach ratio,
+// and there are at least minRequests requests
+func WithClassicFailureRatio(ratio float64, minRequests int) ClassicOptionFn {
+	return func(option *classicOption) {
+		option.failureRatio = ratio
+		option.minRequests = int64(minRequests)
+	}
+}
+
+// WithClassicWindow set the rolling window used by failure ratio
+func WithClassicWindow(windowTime time.Duration, bucketCount int) ClassicOptionFn {
+	return func(option *classicOption) {
+		option.windowTime = windowTime
+		option.bucketCount = bucketCount
+	}
+}
+
+// WithClassicOpenTimeout set how long the breaker stays open before half-open
+func WithClassicOpenTimeout(timeout time.Duration) ClassicOptionFn {
+	return func(option *classicOption) {
+		option.openTimeout = timeout
+	}
+}
+
+// WithClassicHalfOpenQuota set how many probe requests are allowed in half-open state
+func WithClassicHalfOpenQuota(quota int) ClassicOptionFn {
+	return func(option *classicOption) {
+		option.halfOpenQuota = int64(quota)
+	}
+}
+
+func defaultClassicOption() *classicOption {
+	return &classicOption{
+		consecutiveFailures: 5,
+		windowTime:          time.Second * 10,
+		bucketCount:         40,
+		openTimeout:         time.Second * 5,
+		halfOpenQuota:       1,
+	}
+}
+
+func newClassic(options ...ClassicOptionFn) *classic {
+	op := defaultClassicOption()
+	for i := range options {
+		options[i](op)
+	}
+	if op.halfOpenQuota <= 0 {
+		op.halfOpenQuota = 1
+	}
+	if op.bucketCount <= 0 {
+		op.bucketCount = defaultClassicOption().bucketCount
+	}
+
+	c := &classic{option: op}
+	c.resetWindow()
+	return c
+}
+
+func (c *classic) Allow() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.checkOpenTimeout(time.Now())
+
+	switch c.state {
+	case StateOpen:
+		return ErrClassicBreakOn
+	case StateHalfOpen:
+		if c.probes >= c.option.halfOpenQuota {
+			return ErrClassicTooManyRequests
+		}
+		c.probes++
+	}
+
+	return nil
+}
+
+func (c *classic) MarkSuccess() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	switch c.state {
+	case StateClosed:
+		c.consecutive = 0
+		c.rw.Add(1)
+	case StateHalfOpen:
+		c.successes++
+		if c.successes >= c.option.halfOpenQuota {
+			c.setState(StateClosed)
+		}
+	}
+}
+
+func (c *classic) MarkFail() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	switch c.state {
+	case StateClosed:
+		c.consecutive++
+		c.rw.Add(0)
+		if c.shouldTrip() {
+			c.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		c.setState(StateOpen)
+	}
+}
+
+func (c *classic) DoWithAcceptable(req func() error, acceptable func(err error) bool) error {
+	return c.doReq(req, nil, acceptable)
+}
+
+func (c *classic) DoWithFallback(req func() error, fallback func(err error) error, acceptable func(err error) bool) error {
+	return c.doReq(req, fallback, acceptable)
+}
+
+func (c *classic) doReq(req func() error, reject func(err error) error, acceptable func(err error) bool) error {
+	if err := c.Allow(); err != nil {
+		if reject != nil {
+			return reject(err)
+		}
+		return err
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			c.MarkFail()
+			panic(e)
+		}
+	}()
+
+	err := req()
+	if acceptable(err) {
+		c.MarkSuccess()
+	} else {
+		c.MarkFail()
+	}
+	return err
+}
+
+// State return current state of the breaker
+func (c *classic) State() State {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.checkOpenTimeout(time.Now())
+	return c.state
+}
+
+func (c *classic) shouldTrip() bool {
+	if c.option.consecutiveFailures > 0 && c.consecutive >= c.option.consecutiveFailures {
+		return true
+	}
+
+	if c.option.failureRatio <= 0 {
+		return false
+	}
+
+	accepts, total := c.stat()
+	if total == 0 || total < c.option.minRequests {
+		return false
+	}
+
+	return float64(total-accepts)/float64(total) >= c.option.failureRatio
+}
+
+func (c *classic) stat() (accepts, total int64) {
+	c.rw.Reduce(func(bucket *rollingwindow.Bucket) {
+		accepts += int64(bucket.Sum)
+		total += bucket.Count
+	})
+	return accepts, total
+}
+
+// checkOpenTimeout must be called with lock
+func (c *classic) checkOpenTimeout(now time.Time) {
+	if c.state == StateOpen && now.Sub(c.openAt) >= c.option.openTimeout {
+		c.setState(StateHalfOpen)
+	}
+}
+
+// setState must be called with lock
+func (c *classic) setState(state State) {
+	if c.state == state {
+		return
+	}
+
+	log.Infof("classic breaker %s state changed from %s to %s", c.name, c.state, state)
+	from := c.state
+	c.state = state
+	c.consecutive = 0
+	c.probes = 0
+	c.successes = 0
+
+	switch state {
+	case StateOpen:
+		c.openAt = time.Now()
+	case StateClosed:
+		c.resetWindow()
+	}
+
+	if c.onStateChange != nil {
+		c.onStateChange(from, state)
+	}
+}
+
+func (c *classic) setName(name string) {
+	c.lock.Lock()
+	c.name = name
+	c.lock.Unlock()
+}
+
+func (c *classic) setStateListener(fn func(from, to State)) {
+	c.lock.Lock()
+	c.onStateChange = fn
+	c.lock.Unlock()
+}
+
+func (c *classic) snapshot() Stat {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.checkOpenTimeout(time.Now())
+	accepts, total := c.stat()
+	stat := Stat{
+		State:   c.state,
+		Accepts: accepts,
+		Total:   total,
+	}
+	if c.state != StateClosed {
+		// only probes could pass in half-open state
+		stat.DropRatio = 1
+	}
+	return stat
+}
+
+func (c *classic) resetWindow() {
+	c.rw = rollingwindow.New(c.option.bucketCount, time.Duration(int64(c.option.windowTime)/int64(c.option.bucketCount)))
+}
@@ -0,0 +1,127 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassic_ConsecutiveFailures(t *testing.T) {
+	c := newClassic(WithClassicConsecutiveFailures(3), WithClassicOpenTimeout(time.Millisecond*50))
+
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, c.Allow())
+		c.MarkFail()
+	}
+	assert.Nil(t, c.Allow())
+	c.MarkSuccess()
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, c.Allow())
+		c.MarkFail()
+	}
+	assert.Equal(t, StateOpen, c.State())
+	assert.ErrorIs(t, c.Allow(), ErrClassicBreakOn)
+
+	time.Sleep(time.Millisecond * 60)
+	assert.Equal(t, StateHalfOpen, c.State())
+}
+
+func TestClassic_FailureRatio(t *testing.T) {
+	c := newClassic(
+		WithClassicConsecutiveFailures(0),
+		WithClassicFailureRatio(0.5, 10),
+		WithClassicWindow(time.Second, 10),
+	)
+
+	// not enough requests
+	for i := 0; i < 5; i++ {
+		c.MarkFail()
+	}
+	assert.Equal(t, StateClosed, c.State())
+
+	for i := 0; i < 4; i++ {
+		c.MarkSuccess()
+	}
+	assert.Equal(t, StateClosed, c.State())
+
+	c.MarkFail()
+	assert.Equal(t, StateOpen, c.State())
+}
+
+func TestClassic_HalfOpen(t *testing.T) {
+	newOpened := func() *classic {
+		c := newClassic(
+			WithClassicConsecutiveFailures(1),
+			WithClassicOpenTimeout(time.Millisecond*20),
+			WithClassicHalfOpenQuota(2),
+		)
+		c.MarkFail()
+		assert.Equal(t, StateOpen, c.State())
+		time.Sleep(time.Millisecond * 30)
+		return c
+	}
+
+	t.Run("probes succeed", func(t *testing.T) {
+		c := newOpened()
+		assert.Nil(t, c.Allow())
+		assert.Nil(t, c.Allow())
+		assert.ErrorIs(t, c.Allow(), ErrClassicTooManyRequests)
+
+		c.MarkSuccess()
+		assert.Equal(t, StateHalfOpen, c.State())
+		c.MarkSuccess()
+		assert.Equal(t, StateClosed, c.State())
+		assert.Nil(t, c.Allow())
+	})
+
+	t.Run("probe failed", func(t *testing.T) {
+		c := newOpened()
+		assert.Nil(t, c.Allow())
+		c.MarkFail()
+		assert.Equal(t, StateOpen, c.State())
+		assert.ErrorIs(t, c.Allow(), ErrClassicBreakOn)
+	})
+}
+
+func TestClassic_DoWithAcceptable(t *testing.T) {
+	var (
+		c      = newClassic(WithClassicConsecutiveFailures(2))
+		errFoo = errors.New("foo")
+		calls  int
+	)
+
+	for i := 0; i < 3; i++ {
+		err := c.DoWithAcceptable(func() error {
+			calls++
+			return errFoo
+		}, func(err error) bool {
+			return err == nil
+		})
+		if i < 2 {
+			assert.ErrorIs(t, err, errFoo)
+		} else {
+			assert.ErrorIs(t, err, ErrClassicBreakOn)
+		}
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestNew_Selectable(t *testing.T) {
+	b := New(WithClassic(WithClassicConsecutiveFailures(1)))
+	_, ok := b.(*wrapBreaker).Breaker.(*classic)
+	assert.True(t, ok)
+
+	b = New(WithGoogleSre(WithGoogleSreK(2)))
+	sre, ok := b.(*wrapBreaker).Breaker.(*googleSre)
+	assert.True(t, ok)
+	assert.Equal(t, float64(2), sre.option.sreK)
+
+	b = Get("classic-selectable", WithClassic())
+	_, ok = b.(*wrapBreaker).Breaker.(*classic)
+	assert.True(t, ok)
+	assert.Equal(t, "classic-selectable", b.(*wrapBreaker).name)
+	assert.Equal(t, "classic-selectable", b.(*wrapBreaker).Breaker.(*classic).name)
+}
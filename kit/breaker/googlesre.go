@@ -14,7 +14,7 @@ import (
 var ErrGoogleSreBreakOn = errors.New("google sre breaker is on")
 
 type (
-	googleSreOptionFn func(*googleSreOption)
+	GoogleSreOptionFn func(*googleSreOption)
 
 	googleSreOption struct {
 		windowTime  time.Duration
@@ -28,26 +28,53 @@ type (
 		rw     *rollingwindow.RollingWindow
 		lock   sync.Mutex
 		rand   *rand.Rand
+
+		// state is open while dropping requests by probability
+		state         State
+		onStateChange func(from, to State)
 	}
 )
 
-func withGoogleSreWindow(windowTime time.Duration) googleSreOptionFn {
+// WithGoogleSreWindow set the time of the rolling window which stat requests
+func WithGoogleSreWindow(windowTime time.Duration) GoogleSreOptionFn {
 	return func(option *googleSreOption) {
 		option.windowTime = windowTime
 	}
 }
 
-func withGoogleSreBucket(count int) googleSreOptionFn {
+// WithGoogleSreBucket set the bucket count of the rolling window
+func WithGoogleSreBucket(count int) GoogleSreOptionFn {
 	return func(option *googleSreOption) {
 		option.bucketCount = count
 	}
 }
 
-func newGoogleSre(options ...googleSreOptionFn) *googleSre {
+// WithGoogleSreK set the multiplier K of accepts, the smaller K is, the more aggressive breaker drops
+func WithGoogleSreK(k float64) GoogleSreOptionFn {
+	return func(option *googleSreOption) {
+		option.sreK = k
+	}
+}
+
+// WithGoogleSreProtection set the count of requests which always pass, even all requests failed
+func WithGoogleSreProtection(protection int) GoogleSreOptionFn {
+	return func(option *googleSreOption) {
+		option.protection = protection
+	}
+}
+
+func newGoogleSre(options ...GoogleSreOptionFn) *googleSre {
 	op := defaultGoogleSreOption()
 	for i := range options {
 		options[i](op)
 	}
+	if op.bucketCount <= 0 {
+		op.bucketCount = defaultGoogleSreOption().bucketCount
+	}
+	// every bucket must last at least 1ns
+	if op.windowTime < time.Duration(op.bucketCount) {
+		op.windowTime = defaultGoogleSreOption().windowTime
+	}
 
 	return &googleSre{
 		option: op,
@@ -76,10 +103,7 @@ func (g *googleSre) MarkFail() {
 
 func (g *googleSre) Allow() error {
 	accepts, total := g.stat()
-	weight := g.option.sreK*float64(accepts) + float64(g.option.protection)
-	// from 《google sre》
-	// sreK++ ==> dropRatio--
-	dropRatio := math.Max(0, (float64(total)-weight)/float64(total+1))
+	dropRatio := g.dropRatio(accepts, total)
 
 	if g.shouldDrop(dropRatio) {
 		log.Errorf("accepts:%d, total:%d, dropRatio:%v", accepts, total, dropRatio)
@@ -89,6 +113,13 @@ func (g *googleSre) Allow() error {
 	return nil
 }
 
+func (g *googleSre) dropRatio(accepts, total int64) float64 {
+	weight := g.option.sreK*float64(accepts) + float64(g.option.protection)
+	// from 《google sre》
+	// sreK++ ==> dropRatio--
+	return math.Max(0, (float64(total)-weight)/float64(total+1))
+}
+
 func (g *googleSre) stat() (accepts, total int64) {
 	g.rw.Reduce(func(bucket *rollingwindow.Bucket) {
 		accepts += int64(bucket.Sum)
@@ -101,16 +132,56 @@ func (g *googleSre) shouldDrop(dropRatio float64) (should bool) {
 	g.lock.Lock()
 	defer g.lock.Unlock()
 	if dropRatio <= 0 {
+		g.setState(StateClosed)
 		return false
 	}
 
+	g.setState(StateOpen)
 	return g.rand.Float64() < dropRatio
 }
 
+// setState must be called with lock
+func (g *googleSre) setState(state State) {
+	if g.state == state {
+		return
+	}
+
+	from := g.state
+	g.state = state
+	if g.onStateChange != nil {
+		g.onStateChange(from, state)
+	}
+}
+
+func (g *googleSre) setStateListener(fn func(from, to State)) {
+	g.lock.Lock()
+	g.onStateChange = fn
+	g.lock.Unlock()
+}
+
+func (g *googleSre) snapshot() Stat {
+	accepts, total := g.stat()
+
+	g.lock.Lock()
+	state := g.state
+	g.lock.Unlock()
+
+	return Stat{
+		State:     state,
+		DropRatio: g.dropRatio(accepts, total),
+		Accepts:   accepts,
+		Total:     total,
+	}
+}
+
 func (g *googleSre) DoWithAcceptable(req func() error, acceptable func(err error) bool) error {
 	return g.doReq(req, nil, acceptable)
 }
 
+func (g *googleSre) DoWithFallback(req func() error, fallback func(err error) error, acceptable func(err error) bool) error {
+	return g.doReq(req, fallback, acceptable)
+}
+
 func (g *googleSre) doReq(req func() error, reject func(err error) error, acceptable func(err error) bool) error {
 	if err := g.Allow(); err != nil {
 		if reject != nil {
@@ -15,19 +15,47 @@ const (
 )
 
 func getTestGoogleSre() *googleSre {
-	return newGoogleSre(withGoogleSreBucket(testBucketSize), withGoogleSreWindow(10*testBucketTime))
+	return newGoogleSre(WithGoogleSreBucket(testBucketSize), WithGoogleSreWindow(10*testBucketTime))
 }
 
 func TestGoogleSre_With(t *testing.T) {
-	t.Run("withGoogleSreWindow", func(t *testing.T) {
-		sre := newGoogleSre(withGoogleSreWindow(time.Second * 999))
+	t.Run("WithGoogleSreWindow", func(t *testing.T) {
+		sre := newGoogleSre(WithGoogleSreWindow(time.Second * 999))
 		assert.Equal(t, time.Second*999, sre.option.windowTime)
 	})
 
-	t.Run("withGoogleSreBucket", func(t *testing.T) {
-		sre := newGoogleSre(withGoogleSreBucket(2233))
+	t.Run("WithGoogleSreBucket", func(t *testing.T) {
+		sre := newGoogleSre(WithGoogleSreBucket(2233))
 		assert.Equal(t, 2233, sre.option.bucketCount)
 	})
+
+	t.Run("InvalidWindow", func(t *testing.T) {
+		dft := defaultGoogleSreOption()
+		for _, sre := range []*googleSre{
+			newGoogleSre(WithGoogleSreBucket(0)),
+			newGoogleSre(WithGoogleSreBucket(-1)),
+		} {
+			assert.Equal(t, dft.bucketCount, sre.option.bucketCount)
+			assert.Nil(t, sre.Allow())
+		}
+
+		sre := newGoogleSre(WithGoogleSreWindow(time.Nanosecond*10), WithGoogleSreBucket(20))
+		assert.Equal(t, dft.windowTime, sre.option.windowTime)
+		sre = newGoogleSre(WithGoogleSreWindow(0))
+		assert.Equal(t, dft.windowTime, sre.option.windowTime)
+		sre.MarkSuccess()
+		assert.Nil(t, sre.Allow())
+	})
+
+	t.Run("WithGoogleSreK", func(t *testing.T) {
+		sre := newGoogleSre(WithGoogleSreK(2))
+		assert.Equal(t, float64(2), sre.option.sreK)
+	})
+
+	t.Run("WithGoogleSreProtection", func(t *testing.T) {
+		sre := newGoogleSre(WithGoogleSreProtection(10))
+		assert.Equal(t, 10, sre.option.protection)
+	})
 }
 
 func TestGoogleSre_OFF(t *testing.T) {
@@ -1,11 +1,18 @@
 package breaker
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/sado0823/go-kitx/pkg/stringx"
 )
 
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
 var (
 	lock     sync.RWMutex
 	breakers = make(map[string]Breaker)
@@ -17,37 +24,113 @@ type (
 		MarkSuccess()
 		MarkFail()
 		DoWithAcceptable(req func() error, acceptable func(err error) bool) error
+		// DoWithFallback calls fallback instead of req when the breaker rejects
+		DoWithFallback(req func() error, fallback func(err error) error, acceptable func(err error) bool) error
+	}
+
+	State int32
+
+	// StateListener is called with the lock of breaker held, it should not call back into the breaker
+	StateListener func(name string, from, to State)
+
+	// Stat is a snapshot of a breaker
+	Stat struct {
+		Name      string
+		State     State
+		DropRatio float64
+		// Accepts and Total are the requests in current window
+		Accepts int64
+		Total   int64
 	}
 
 	OptionFn func(*wrapBreaker)
 
 	wrapBreaker struct {
-		name string
+		name      string
+		listeners []StateListener
 		Breaker
 	}
+
+	// observable is implemented by breakers which could report state changes
+	observable interface {
+		setStateListener(fn func(from, to State))
+	}
+
+	// named is implemented by breakers which log with their name
+	named interface {
+		setName(name string)
+	}
+
+	statistic interface {
+		snapshot() Stat
+	}
 )
 
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
 func WithName(name string) OptionFn {
 	return func(breaker *wrapBreaker) {
 		breaker.name = name
 	}
 }
 
+// WithStateListener is called when the state of breaker changed, e.g. closed -> open
+func WithStateListener(listener StateListener) OptionFn {
+	return func(breaker *wrapBreaker) {
+		breaker.listeners = append(breaker.listeners, listener)
+	}
+}
+
+// WithGoogleSre use the google sre breaker which drops requests by probability, it's the default one
+func WithGoogleSre(options ...GoogleSreOptionFn) OptionFn {
+	return func(breaker *wrapBreaker) {
+		breaker.Breaker = newGoogleSre(options...)
+	}
+}
+
+// WithClassic use the closed/open/half-open state machine breaker which trips deterministically
+func WithClassic(options ...ClassicOptionFn) OptionFn {
+	return func(breaker *wrapBreaker) {
+		breaker.Breaker = newClassic(options...)
+	}
+}
+
 func New(options ...OptionFn) Breaker {
-	b := &wrapBreaker{Breaker: newGoogleSre()}
+	b := &wrapBreaker{}
 
 	for i := range options {
 		options[i](b)
 	}
+	if b.Breaker == nil {
+		b.Breaker = newGoogleSre()
+	}
 	if b.name == "" {
 		b.name = stringx.Rand(8)
 	}
+	if n, ok := b.Breaker.(named); ok {
+		n.setName(b.name)
+	}
+	if o, ok := b.Breaker.(observable); ok && len(b.listeners) > 0 {
+		o.setStateListener(b.onStateChange)
+	}
 
 	return b
 }
 
-// Get a breaker with name, if existed, return old one
-func Get(name string) Breaker {
+// Get a breaker with name, if existed, return old one.
+// options are only used when the breaker is created
+func Get(name string, options ...OptionFn) Breaker {
 	lock.RLock()
 	b, ok := breakers[name]
 	lock.RUnlock()
@@ -58,7 +141,10 @@ func Get(name string) Breaker {
 	lock.Lock()
 	b, ok = breakers[name]
 	if !ok {
-		b = New(WithName(name))
+		// copy options, appending to them may write into the backing array of caller
+		withName := make([]OptionFn, 0, len(options)+1)
+		withName = append(append(withName, options...), WithName(name))
+		b = New(withName...)
 		breakers[name] = b
 	}
 	lock.Unlock()
@@ -72,3 +158,37 @@ func Except(name string) {
 	breakers[name] = &noob{}
 	lock.Unlock()
 }
+
+// List return stats of all breakers created by Get, sorted by name
+func List() []Stat {
+	lock.RLock()
+	stats := make([]Stat, 0, len(breakers))
+	for name, b := range breakers {
+		stat := Stat{Name: name}
+		if s, ok := b.(statistic); ok {
+			stat = s.snapshot()
+		}
+		stats = append(stats, stat)
+	}
+	lock.RUnlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+func (w *wrapBreaker) onStateChange(from, to State) {
+	for _, listener := range w.listeners {
+		listener(w.name, from, to)
+	}
+}
+
+func (w *wrapBreaker) snapshot() Stat {
+	var stat Stat
+	if s, ok := w.Breaker.(statistic); ok {
+		stat = s.snapshot()
+	}
+	stat.Name = w.name
+	return stat
+}
@@ -0,0 +1,130 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/pkg/stringx"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateListener(t *testing.T) {
+	t.Run("classic", func(t *testing.T) {
+		var (
+			mu          sync.Mutex
+			transitions []State
+		)
+		b := New(WithName("listener-classic"), WithClassic(
+			WithClassicConsecutiveFailures(1),
+			WithClassicOpenTimeout(time.Millisecond*10),
+		), WithStateListener(func(name string, from, to State) {
+			assert.Equal(t, "listener-classic", name)
+			mu.Lock()
+			transitions = append(transitions, to)
+			mu.Unlock()
+		}))
+
+		assert.Nil(t, b.Allow())
+		b.MarkFail()
+		time.Sleep(time.Millisecond * 20)
+		assert.Nil(t, b.Allow())
+		b.MarkSuccess()
+
+		mu.Lock()
+		assert.Equal(t, []State{StateOpen, StateHalfOpen, StateClosed}, transitions)
+		mu.Unlock()
+	})
+
+	t.Run("google sre", func(t *testing.T) {
+		var transitions []State
+		b := New(WithGoogleSre(
+			WithGoogleSreBucket(testBucketSize),
+			WithGoogleSreWindow(10*testBucketTime),
+		), WithStateListener(func(name string, from, to State) {
+			transitions = append(transitions, to)
+		}))
+
+		for i := 0; i < 1000; i++ {
+			if b.Allow() == nil {
+				b.MarkFail()
+			}
+		}
+		assert.Contains(t, transitions, StateOpen)
+
+		time.Sleep(testBucketTime * (testBucketSize + 1))
+		assert.Nil(t, b.Allow())
+		assert.Equal(t, StateClosed, transitions[len(transitions)-1])
+	})
+}
+
+func TestDoWithFallback(t *testing.T) {
+	var (
+		errFallback = errors.New("fallback")
+		acceptable  = func(err error) bool { return err == nil }
+	)
+
+	for name, b := range map[string]Breaker{
+		"classic":    New(WithClassic(WithClassicConsecutiveFailures(1))),
+		"google sre": New(WithGoogleSre(WithGoogleSreProtection(0), WithGoogleSreK(0.01))),
+	} {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				_ = b.DoWithAcceptable(func() error {
+					return errors.New("foo")
+				}, acceptable)
+			}
+
+			var called bool
+			err := b.DoWithFallback(func() error {
+				called = true
+				return nil
+			}, func(err error) error {
+				assert.NotNil(t, err)
+				return errFallback
+			}, acceptable)
+			if called {
+				// google sre might let it through by probability
+				assert.Nil(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, errFallback)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	prefix := "list-" + stringx.Rand(8) + "-"
+	Get(prefix+"b", WithClassic(WithClassicConsecutiveFailures(1))).MarkFail()
+	Get(prefix+"a").MarkSuccess()
+	Except(prefix + "c")
+
+	stats := make(map[string]Stat)
+	var names []string
+	for _, stat := range List() {
+		stats[stat.Name] = stat
+		names = append(names, stat.Name)
+	}
+
+	assert.IsIncreasing(t, names)
+	assert.Equal(t, StateOpen, stats[prefix+"b"].State)
+	assert.Equal(t, float64(1), stats[prefix+"b"].DropRatio)
+	assert.Equal(t, StateClosed, stats[prefix+"a"].State)
+	assert.EqualValues(t, 1, stats[prefix+"a"].Accepts)
+	assert.EqualValues(t, 1, stats[prefix+"a"].Total)
+	assert.Equal(t, StateClosed, stats[prefix+"c"].State)
+}
+
+func TestGet_KeepOptions(t *testing.T) {
+	var (
+		sre      = WithGoogleSre()
+		options  = make([]OptionFn, 1, 2)
+		reserved = options[:2]
+	)
+	options[0] = sre
+	Get("keep-options-"+stringx.Rand(8), options...)
+	// the spare capacity of caller is not written
+	assert.Nil(t, reserved[1])
+}
@@ -0,0 +1,110 @@
+package hash
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+const boundedEpsilon = 0.25
+
+type (
+	// BoundedConsistent is a Consistent with bounded loads, each Get takes a load of the returned node,
+	// Done releases it. no node takes more than ceil((1+ε)×average) loads,
+	// a key goes to the next node on the ring when its node is full.
+	// see https://arxiv.org/abs/1608.01350
+	BoundedConsistent interface {
+		Consistent
+		// Done releases a load taken by Get
+		Done(node interface{})
+		// Loads returns current loads of nodes
+		Loads() map[string]int64
+	}
+
+	bounded struct {
+		*consistent
+		epsilon   float64
+		loads     map[string]int64
+		total     int64
+		loadsLock sync.Mutex
+	}
+)
+
+var _ BoundedConsistent = (*bounded)(nil)
+
+// NewBoundedConsistent create a BoundedConsistent, epsilon defaults to 0.25
+func NewBoundedConsistent(epsilon float64, withs ...ConsistentWith) BoundedConsistent {
+	if epsilon <= 0 {
+		epsilon = boundedEpsilon
+	}
+
+	return &bounded{
+		consistent: NewConsistent(withs...).(*consistent),
+		epsilon:    epsilon,
+		loads:      make(map[string]int64),
+	}
+}
+
+func (b *bounded) Get(node interface{}) (value interface{}, has bool) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	if len(b.vtrKeys) == 0 {
+		return nil, false
+	}
+
+	b.loadsLock.Lock()
+	defer b.loadsLock.Unlock()
+
+	maxLoad := int64(math.Ceil((1 + b.epsilon) * float64(b.total+1) / float64(len(b.nodes))))
+	hashV := b.opt.hash([]byte(b.marshal(node)))
+	start := sort.Search(len(b.vtrKeys), func(i int) bool {
+		return b.vtrKeys[i] >= hashV
+	})
+	for i := 0; i < len(b.vtrKeys); i++ {
+		for _, candidate := range b.vtrRing[b.vtrKeys[(start+i)%len(b.vtrKeys)]] {
+			expr := b.marshal(candidate)
+			if b.loads[expr] < maxLoad {
+				b.loads[expr]++
+				b.total++
+				return candidate, true
+			}
+		}
+	}
+
+	// unreachable, the average load is always under the max one
+	return nil, false
+}
+
+func (b *bounded) Remove(node interface{}) {
+	b.consistent.Remove(node)
+
+	expr := b.marshal(node)
+	b.loadsLock.Lock()
+	b.total -= b.loads[expr]
+	delete(b.loads, expr)
+	b.loadsLock.Unlock()
+}
+
+func (b *bounded) Done(node interface{}) {
+	expr := b.marshal(node)
+
+	b.loadsLock.Lock()
+	defer b.loadsLock.Unlock()
+
+	if b.loads[expr] > 0 {
+		b.loads[expr]--
+		b.total--
+	}
+}
+
+func (b *bounded) Loads() map[string]int64 {
+	b.loadsLock.Lock()
+	defer b.loadsLock.Unlock()
+
+	loads := make(map[string]int64, len(b.loads))
+	for k, v := range b.loads {
+		loads[k] = v
+	}
+	return loads
+}
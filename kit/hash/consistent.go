@@ -24,6 +24,13 @@ type (
 		Cascade(node interface{}, opts ...ConsistentAddWith) []uint64
 	}
 
+	// Walker is implemented by rings which could skip nodes, e.g. the ones filtered out per request
+	Walker interface {
+		// GetMatched walks the ring from the position of node, and returns the first value matched,
+		// it's the same as Get when all values match
+		GetMatched(node interface{}, match func(value interface{}) bool) (value interface{}, has bool)
+	}
+
 	consistent struct {
 		opt     *option
 		vtrNum  int64                    // 虚拟节点数量
@@ -166,6 +173,10 @@ func (c *consistent) Add(node interface{}, opts ...ConsistentAddWith) {
 }
 
 func (c *consistent) Get(node interface{}) (value interface{}, has bool) {
+	return c.GetMatched(node, nil)
+}
+
+func (c *consistent) GetMatched(node interface{}, match func(value interface{}) bool) (value interface{}, has bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
@@ -175,21 +186,34 @@ func (c *consistent) Get(node interface{}) (value interface{}, has bool) {
 
 	nodeExpr := c.marshal(node)
 	hashV := c.opt.hash([]byte(nodeExpr))
-	index := sort.Search(len(c.vtrKeys), func(i int) bool {
+	start := sort.Search(len(c.vtrKeys), func(i int) bool {
 		return c.vtrKeys[i] >= hashV
-	}) % len(c.vtrKeys)
+	})
 
-	nodes := c.vtrRing[c.vtrKeys[index]]
-	switch len(nodes) {
-	case 0:
-		return nil, false
-	case 1:
-		return nodes[0], true
-	default:
-		index := c.opt.hash([]byte(c.innerMarshal(node)))
-		pos := int(index % uint64(len(nodes)))
-		return nodes[pos], true
+	for i := 0; i < len(c.vtrKeys); i++ {
+		nodes := c.vtrRing[c.vtrKeys[(start+i)%len(c.vtrKeys)]]
+		if match != nil {
+			matched := make([]interface{}, 0, len(nodes))
+			for _, n := range nodes {
+				if match(n) {
+					matched = append(matched, n)
+				}
+			}
+			nodes = matched
+		}
+
+		switch len(nodes) {
+		case 0:
+			continue
+		case 1:
+			return nodes[0], true
+		default:
+			index := c.opt.hash([]byte(c.innerMarshal(node)))
+			pos := int(index % uint64(len(nodes)))
+			return nodes[pos], true
+		}
 	}
+	return nil, false
 }
 
 func (c *consistent) Remove(node interface{}) {
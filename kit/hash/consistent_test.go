@@ -22,6 +22,35 @@ func Test_NewConsistent(t *testing.T) {
 	})
 }
 
+func Test_Consistent_GetMatched(t *testing.T) {
+	ch := NewConsistent()
+	walker := ch.(Walker)
+	_, ok := walker.GetMatched("key", nil)
+	assert.False(t, ok)
+
+	for i := 0; i < 20; i++ {
+		ch.Add(i)
+	}
+	even := func(value interface{}) bool { return value.(int)%2 == 0 }
+	for i := 0; i < 1000; i++ {
+		value, ok := ch.Get(i)
+		assert.True(t, ok)
+		all, ok := walker.GetMatched(i, nil)
+		assert.True(t, ok)
+		assert.Equal(t, value, all)
+
+		matched, ok := walker.GetMatched(i, even)
+		assert.True(t, ok)
+		assert.True(t, even(matched))
+		if even(value) {
+			assert.Equal(t, value, matched)
+		}
+	}
+
+	_, ok = walker.GetMatched("key", func(interface{}) bool { return false })
+	assert.False(t, ok)
+}
+
 func Test_Consistent_Get(t *testing.T) {
 	ch := NewConsistent()
 	for i := 0; i < 20; i++ {
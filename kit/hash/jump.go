@@ -0,0 +1,107 @@
+package hash
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/spaolacci/murmur3"
+)
+
+type (
+	// jump is jump consistent hashing, it's fast and needs no memory but nodes are numbered buckets,
+	// a removed node is replaced by the last one, so keys of both of them move.
+	// see https://arxiv.org/abs/1406.2294
+	jump struct {
+		opt   *option
+		nodes []interface{}
+		index map[string]int
+		lock  sync.RWMutex
+	}
+)
+
+var _ Consistent = (*jump)(nil)
+
+// NewJump create a Consistent with jump hashing, ConsistentWithVtr and ConsistentAddWith are ignored
+func NewJump(withs ...ConsistentWith) Consistent {
+	dft := &option{hash: murmur3.Sum64}
+	for i := range withs {
+		withs[i](dft)
+	}
+	if dft.hash == nil {
+		dft.hash = murmur3.Sum64
+	}
+
+	return &jump{opt: dft, index: make(map[string]int)}
+}
+
+// Cascade returns the bucket of node, or nil if node is not added
+func (j *jump) Cascade(node interface{}, opts ...ConsistentAddWith) []uint64 {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	if i, ok := j.index[j.marshal(node)]; ok {
+		return []uint64{uint64(i)}
+	}
+	return nil
+}
+
+func (j *jump) Add(node interface{}, opts ...ConsistentAddWith) {
+	expr := j.marshal(node)
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if i, ok := j.index[expr]; ok {
+		j.nodes[i] = node
+		return
+	}
+	j.index[expr] = len(j.nodes)
+	j.nodes = append(j.nodes, node)
+}
+
+func (j *jump) Get(node interface{}) (value interface{}, has bool) {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	if len(j.nodes) == 0 {
+		return nil, false
+	}
+
+	bucket := jumpHash(j.opt.hash([]byte(j.marshal(node))), len(j.nodes))
+	return j.nodes[bucket], true
+}
+
+func (j *jump) Remove(node interface{}) {
+	expr := j.marshal(node)
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	i, ok := j.index[expr]
+	if !ok {
+		return
+	}
+
+	last := len(j.nodes) - 1
+	if i != last {
+		j.nodes[i] = j.nodes[last]
+		j.index[j.marshal(j.nodes[i])] = i
+	}
+	j.nodes[last] = nil
+	j.nodes = j.nodes[:last]
+	delete(j.index, expr)
+}
+
+func (j *jump) marshal(v interface{}) string {
+	return fmt.Sprintf("%v", v)
+}
+
+func jumpHash(key uint64, buckets int) int {
+	var b, i int64 = -1, 0
+	for i < int64(buckets) {
+		b = i
+		key = key*2862933555777941757 + 1
+		i = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
@@ -0,0 +1,65 @@
+package hash
+
+import (
+	"strconv"
+	"testing"
+)
+
+// BenchmarkKeyMovement reports the ratio of keys moved when a node is added or removed,
+// the ideal one is 1/11 for adding an 11th node and 1/10 for removing one of 10
+func BenchmarkKeyMovement(b *testing.B) {
+	const (
+		nodes = 10
+		keys  = 10000
+	)
+
+	mapping := func(ch Consistent) []interface{} {
+		values := make([]interface{}, keys)
+		for i := range values {
+			values[i], _ = ch.Get(i)
+			if bc, ok := ch.(BoundedConsistent); ok {
+				bc.Done(values[i])
+			}
+		}
+		return values
+	}
+	moved := func(before, after []interface{}) float64 {
+		var n int
+		for i := range before {
+			if before[i] != after[i] {
+				n++
+			}
+		}
+		return float64(n) / float64(len(before))
+	}
+
+	for name, create := range strategies {
+		create := create
+		b.Run(name+"/add", func(b *testing.B) {
+			var ratio float64
+			for i := 0; i < b.N; i++ {
+				ch := create()
+				for j := 0; j < nodes; j++ {
+					ch.Add("node" + strconv.Itoa(j))
+				}
+				before := mapping(ch)
+				ch.Add("node" + strconv.Itoa(nodes))
+				ratio = moved(before, mapping(ch))
+			}
+			b.ReportMetric(ratio, "moved/keys")
+		})
+		b.Run(name+"/remove", func(b *testing.B) {
+			var ratio float64
+			for i := 0; i < b.N; i++ {
+				ch := create()
+				for j := 0; j < nodes; j++ {
+					ch.Add("node" + strconv.Itoa(j))
+				}
+				before := mapping(ch)
+				ch.Remove("node3")
+				ratio = moved(before, mapping(ch))
+			}
+			b.ReportMetric(ratio, "moved/keys")
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package hash
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/spaolacci/murmur3"
+)
+
+type (
+	rendezvousNode struct {
+		node   interface{}
+		expr   string
+		hash   uint64
+		weight float64
+	}
+
+	// rendezvous is highest random weight hashing, each key goes to the node with the highest score of hash(node, key),
+	// only keys of the added or removed node move, and no virtual nodes are needed.
+	// see https://en.wikipedia.org/wiki/Rendezvous_hashing
+	rendezvous struct {
+		opt   *option
+		nodes []*rendezvousNode
+		lock  sync.RWMutex
+	}
+)
+
+var _ Consistent = (*rendezvous)(nil)
+
+// NewRendezvous create a Consistent with rendezvous hashing, ConsistentWithVtr is ignored,
+// ConsistentAddWithWeight is supported
+func NewRendezvous(withs ...ConsistentWith) Consistent {
+	dft := &option{hash: murmur3.Sum64}
+	for i := range withs {
+		withs[i](dft)
+	}
+	if dft.hash == nil {
+		dft.hash = murmur3.Sum64
+	}
+
+	return &rendezvous{opt: dft}
+}
+
+// Cascade returns the hash of node, which is mixed with key hash for scores
+func (r *rendezvous) Cascade(node interface{}, opts ...ConsistentAddWith) []uint64 {
+	return []uint64{r.opt.hash([]byte(r.marshal(node)))}
+}
+
+func (r *rendezvous) Add(node interface{}, opts ...ConsistentAddWith) {
+	dft := &optionAdd{weight: maxWeight}
+	for i := range opts {
+		opts[i](dft)
+	}
+	if dft.weight <= 0 || dft.weight > maxWeight {
+		dft.weight = maxWeight
+	}
+
+	expr := r.marshal(node)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	n := &rendezvousNode{
+		node:   node,
+		expr:   expr,
+		hash:   r.opt.hash([]byte(expr)),
+		weight: float64(dft.weight) / maxWeight,
+	}
+	for i := range r.nodes {
+		if r.nodes[i].expr == expr {
+			r.nodes[i] = n
+			return
+		}
+	}
+	r.nodes = append(r.nodes, n)
+}
+
+func (r *rendezvous) Get(node interface{}) (value interface{}, has bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if len(r.nodes) == 0 {
+		return nil, false
+	}
+
+	keyHash := r.opt.hash([]byte(r.marshal(node)))
+	var (
+		best      *rendezvousNode
+		bestScore = math.Inf(-1)
+	)
+	for _, n := range r.nodes {
+		if score := n.score(keyHash); score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	return best.node, true
+}
+
+func (r *rendezvous) Remove(node interface{}) {
+	expr := r.marshal(node)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i := range r.nodes {
+		if r.nodes[i].expr == expr {
+			r.nodes = append(r.nodes[:i], r.nodes[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *rendezvous) marshal(v interface{}) string {
+	return fmt.Sprintf("%v", v)
+}
+
+// score is weighted by -w/ln(h), h is hash(node, key) mapped to (0, 1),
+// so that each node takes keys in proportion to its weight.
+// see https://www.snia.org/sites/default/files/SDC15_presentations/dist_sys/Jason_Resch_New_Consistent_Hashings_Rev.pdf
+func (n *rendezvousNode) score(keyHash uint64) float64 {
+	h := (float64(mix(n.hash^keyHash)>>11) + 0.5) / (1 << 53)
+	return -n.weight / math.Log(h)
+}
+
+// mix is the finalizer of murmur3, which spreads combined hashes evenly
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
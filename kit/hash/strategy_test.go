@@ -0,0 +1,124 @@
+package hash
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var strategies = map[string]func() Consistent{
+	"ring": func() Consistent {
+		return NewConsistent()
+	},
+	"rendezvous": func() Consistent {
+		return NewRendezvous()
+	},
+	"jump": func() Consistent {
+		return NewJump()
+	},
+	"bounded": func() Consistent {
+		return NewBoundedConsistent(0.25)
+	},
+}
+
+func TestStrategies(t *testing.T) {
+	for name, create := range strategies {
+		t.Run(name, func(t *testing.T) {
+			ch := create()
+			val, ok := ch.Get("any")
+			assert.False(t, ok)
+			assert.Nil(t, val)
+
+			for i := 0; i < 20; i++ {
+				ch.Add("localhost:" + strconv.Itoa(i))
+			}
+
+			keys := make(map[interface{}]int)
+			for i := 0; i < 10000; i++ {
+				key, ok := ch.Get(i)
+				assert.True(t, ok)
+				keys[key]++
+			}
+			assert.Len(t, keys, 20)
+			assert.True(t, calcEntropy(keys) > .95)
+
+			ch.Remove("localhost:3")
+			ch.Remove("not exists")
+			for i := 0; i < 1000; i++ {
+				key, ok := ch.Get(i)
+				assert.True(t, ok)
+				assert.NotEqual(t, "localhost:3", key)
+			}
+		})
+	}
+}
+
+func TestRendezvous_Weight(t *testing.T) {
+	ch := NewRendezvous()
+	ch.Add("light", ConsistentAddWithWeight(25))
+	ch.Add("heavy")
+
+	keys := make(map[interface{}]int)
+	for i := 0; i < 10000; i++ {
+		key, _ := ch.Get(i)
+		keys[key]++
+	}
+	assert.InDelta(t, 0.2, float64(keys["light"])/10000, 0.03)
+}
+
+func TestJump(t *testing.T) {
+	ch := NewJump()
+	for i := 0; i < 5; i++ {
+		ch.Add(i)
+	}
+	assert.Equal(t, []uint64{2}, ch.Cascade(2))
+
+	// the last one takes place of the removed one
+	ch.Remove(2)
+	assert.Nil(t, ch.Cascade(2))
+	assert.Equal(t, []uint64{2}, ch.Cascade(4))
+
+	for i := 0; i < 1000; i++ {
+		b := jumpHash(uint64(i), 10)
+		assert.True(t, b >= 0 && b < 10)
+	}
+}
+
+func TestBoundedConsistent(t *testing.T) {
+	const (
+		nodes = 10
+		total = 10000
+	)
+	ch := NewBoundedConsistent(0.1)
+	for i := 0; i < nodes; i++ {
+		ch.Add("localhost:" + strconv.Itoa(i))
+	}
+
+	// all keys on the same node are spread when it's full
+	for i := 0; i < total; i++ {
+		_, ok := ch.Get("hot key")
+		assert.True(t, ok)
+	}
+	loads := ch.Loads()
+	assert.Len(t, loads, nodes)
+	for _, load := range loads {
+		assert.True(t, load <= 1100, load)
+	}
+
+	for node, load := range loads {
+		for i := int64(0); i < load; i++ {
+			ch.Done(node)
+		}
+	}
+	ch.Done("localhost:0")
+	for _, load := range ch.Loads() {
+		assert.Equal(t, int64(0), load)
+	}
+
+	// the node of a key doesn't change if not full
+	first, _ := ch.Get("key")
+	ch.Done(first)
+	second, _ := ch.Get("key")
+	assert.Equal(t, first, second)
+}
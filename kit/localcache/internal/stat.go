@@ -2,6 +2,7 @@ package internal
 
 import (
 	"log"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -9,17 +10,43 @@ import (
 //const statInterval = time.Minute
 const statInterval = time.Second
 
-type Stat struct {
-	name         string
-	hit          uint64
-	miss         uint64
-	sizeCallback func() int
-}
+type (
+	Stat struct {
+		name         string
+		hit          uint64
+		miss         uint64
+		sizeCallback func() int
+
+		// cumulative counters since created
+		totalHit     uint64
+		totalMiss    uint64
+		evict        uint64
+		expire       uint64
+		loadSuccess  uint64
+		loadFail     uint64
+		loadDuration int64
+
+		stopOnce sync.Once
+		stopChan chan struct{}
+	}
+
+	// Snapshot is the cumulative counters of a Stat
+	Snapshot struct {
+		Hit          uint64
+		Miss         uint64
+		Evict        uint64
+		Expire       uint64
+		LoadSuccess  uint64
+		LoadFail     uint64
+		LoadDuration time.Duration
+	}
+)
 
 func NewStat(name string, sizeCallback func() int) *Stat {
 	st := &Stat{
 		name:         name,
 		sizeCallback: sizeCallback,
+		stopChan:     make(chan struct{}),
 	}
 	go st.report()
 
@@ -29,11 +56,33 @@ func NewStat(name string, sizeCallback func() int) *Stat {
 // Hit hit counter++
 func (s *Stat) Hit() {
 	atomic.AddUint64(&s.hit, 1)
+	atomic.AddUint64(&s.totalHit, 1)
 }
 
 // Miss missed counter++
 func (s *Stat) Miss() {
 	atomic.AddUint64(&s.miss, 1)
+	atomic.AddUint64(&s.totalMiss, 1)
+}
+
+// Evict evicted by lru counter++
+func (s *Stat) Evict() {
+	atomic.AddUint64(&s.evict, 1)
+}
+
+// Expire expired by timing wheel counter++
+func (s *Stat) Expire() {
+	atomic.AddUint64(&s.expire, 1)
+}
+
+// Load records a fetch with its cost
+func (s *Stat) Load(cost time.Duration, err error) {
+	if err != nil {
+		atomic.AddUint64(&s.loadFail, 1)
+	} else {
+		atomic.AddUint64(&s.loadSuccess, 1)
+	}
+	atomic.AddInt64(&s.loadDuration, int64(cost))
 }
 
 // CurrentMinute return hit and missed counter in a minute
@@ -43,11 +92,37 @@ func (s *Stat) CurrentMinute() (hit, miss uint64) {
 	return hit, miss
 }
 
+// Snapshot return all the cumulative counters
+func (s *Stat) Snapshot() Snapshot {
+	return Snapshot{
+		Hit:          atomic.LoadUint64(&s.totalHit),
+		Miss:         atomic.LoadUint64(&s.totalMiss),
+		Evict:        atomic.LoadUint64(&s.evict),
+		Expire:       atomic.LoadUint64(&s.expire),
+		LoadSuccess:  atomic.LoadUint64(&s.loadSuccess),
+		LoadFail:     atomic.LoadUint64(&s.loadFail),
+		LoadDuration: time.Duration(atomic.LoadInt64(&s.loadDuration)),
+	}
+}
+
+// Stop stops reporting
+func (s *Stat) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+}
+
 func (s *Stat) report() {
 	ticker := time.NewTicker(statInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+		}
+
 		hit := atomic.SwapUint64(&s.hit, 0)
 		miss := atomic.SwapUint64(&s.miss, 0)
 		total := hit + miss
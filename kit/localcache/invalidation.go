@@ -0,0 +1,208 @@
+package localcache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/log"
+	"github.com/sado0823/go-kitx/kit/store/redis"
+	"github.com/sado0823/go-kitx/pkg/stringx"
+)
+
+const (
+	defaultInvalidationBatchSize = 100
+	defaultInvalidationInterval  = time.Millisecond * 50
+	invalidationRetryInterval    = time.Second
+)
+
+type (
+	InvalidationOption func(*invalidation)
+
+	// invalidation is a bus among all instances sharing the same channel,
+	// keys deleted on one instance are published and evicted by the others
+	invalidation struct {
+		cache     *Cache
+		rds       *redis.Redis
+		channel   string
+		id        string
+		batchSize int
+		interval  time.Duration
+
+		lock    sync.Mutex
+		pending map[string]struct{}
+		flushCh chan struct{}
+		doneCh  chan struct{}
+		once    sync.Once
+		pubsub  *redis.PubSub
+	}
+
+	invalidationMessage struct {
+		From string   `json:"from"`
+		Keys []string `json:"keys"`
+	}
+)
+
+// WithInvalidationBatch publishes deleted keys when size keys are pending or every interval
+func WithInvalidationBatch(size int, interval time.Duration) InvalidationOption {
+	return func(inv *invalidation) {
+		if size > 0 {
+			inv.batchSize = size
+		}
+		if interval > 0 {
+			inv.interval = interval
+		}
+	}
+}
+
+// WithInvalidation makes Del publish the key on the redis channel, every cache
+// subscribing the same channel evicts it.
+// since missed messages can't be detected, the whole cache is flushed after reconnecting
+func WithInvalidation(rds *redis.Redis, channel string, opts ...InvalidationOption) Option {
+	return func(cache *Cache) {
+		inv := &invalidation{
+			cache:     cache,
+			rds:       rds,
+			channel:   channel,
+			id:        stringx.Rand(16),
+			batchSize: defaultInvalidationBatchSize,
+			interval:  defaultInvalidationInterval,
+			pending:   make(map[string]struct{}),
+			flushCh:   make(chan struct{}, 1),
+			doneCh:    make(chan struct{}),
+		}
+		for _, opt := range opts {
+			opt(inv)
+		}
+
+		cache.invalidation = inv
+	}
+}
+
+func (inv *invalidation) start() error {
+	pubsub, err := inv.rds.Subscribe(context.Background(), inv.channel)
+	if err != nil {
+		return err
+	}
+
+	inv.pubsub = pubsub
+	go inv.publishLoop()
+	go inv.subscribeLoop()
+	return nil
+}
+
+func (inv *invalidation) stop() {
+	inv.once.Do(func() {
+		close(inv.doneCh)
+		if err := inv.pubsub.Close(); err != nil {
+			log.Errorf("localcache(%s) close pubsub error: %v", inv.cache.name, err)
+		}
+	})
+}
+
+// publish queues key to be sent in next batch
+func (inv *invalidation) publish(key string) {
+	inv.lock.Lock()
+	inv.pending[key] = struct{}{}
+	full := len(inv.pending) >= inv.batchSize
+	inv.lock.Unlock()
+
+	if full {
+		select {
+		case inv.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (inv *invalidation) publishLoop() {
+	ticker := time.NewTicker(inv.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			inv.flush()
+		case <-inv.flushCh:
+			inv.flush()
+		case <-inv.doneCh:
+			inv.flush()
+			return
+		}
+	}
+}
+
+func (inv *invalidation) flush() {
+	inv.lock.Lock()
+	if len(inv.pending) == 0 {
+		inv.lock.Unlock()
+		return
+	}
+	keys := make([]string, 0, len(inv.pending))
+	for key := range inv.pending {
+		keys = append(keys, key)
+	}
+	inv.pending = make(map[string]struct{})
+	inv.lock.Unlock()
+
+	payload, err := json.Marshal(invalidationMessage{From: inv.id, Keys: keys})
+	if err != nil {
+		log.Errorf("localcache(%s) marshal invalidation error: %v", inv.cache.name, err)
+		return
+	}
+
+	if _, err = inv.rds.Publish(context.Background(), inv.channel, payload); err != nil {
+		log.Errorf("localcache(%s) publish invalidation of %d keys error: %v", inv.cache.name, len(keys), err)
+	}
+}
+
+func (inv *invalidation) subscribeLoop() {
+	var subscribed, lost bool
+	for {
+		msg, err := inv.pubsub.Receive(context.Background())
+		if err != nil {
+			select {
+			case <-inv.doneCh:
+				return
+			default:
+			}
+
+			// the connection is reestablished on next Receive
+			log.Errorf("localcache(%s) receive invalidation error: %v", inv.cache.name, err)
+			lost = true
+			time.Sleep(invalidationRetryInterval)
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind != "subscribe" {
+				continue
+			}
+			if subscribed || lost {
+				// messages might be missed while disconnected, drop everything
+				inv.cache.flush()
+			}
+			subscribed, lost = true, false
+		case *redis.Message:
+			inv.handle(m.Payload)
+		}
+	}
+}
+
+func (inv *invalidation) handle(payload string) {
+	var msg invalidationMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		log.Errorf("localcache(%s) unmarshal invalidation error: %v", inv.cache.name, err)
+		return
+	}
+
+	if msg.From == inv.id {
+		return
+	}
+
+	for _, key := range msg.Keys {
+		inv.cache.del(key)
+	}
+}
@@ -0,0 +1,103 @@
+package localcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/store/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Invalidation(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer mini.Close()
+
+	var (
+		ctx = context.Background()
+		rds = redis.New(mini.Addr())
+	)
+
+	cache1, err := New(time.Second*3, WithInvalidation(rds, "invalidation-test"))
+	assert.Nil(t, err)
+	defer cache1.Close()
+
+	cache2, err := New(time.Second*3, WithInvalidation(rds, "invalidation-test", WithInvalidationBatch(2, time.Second*10)))
+	assert.Nil(t, err)
+	defer cache2.Close()
+
+	// wait for subscriptions
+	time.Sleep(time.Millisecond * 100)
+
+	for _, cache := range []*Cache{cache1, cache2} {
+		cache.Set(ctx, "foo1", "bar1")
+		cache.Set(ctx, "foo2", "bar2")
+		cache.Set(ctx, "foo3", "bar3")
+	}
+
+	cache1.Del(ctx, "foo1")
+	time.Sleep(time.Millisecond * 200)
+
+	_, ok := cache2.Get(ctx, "foo1")
+	assert.False(t, ok)
+	_, ok = cache2.Get(ctx, "foo2")
+	assert.True(t, ok)
+
+	// published only when the batch is full
+	cache2.Del(ctx, "foo2")
+	time.Sleep(time.Millisecond * 200)
+	_, ok = cache1.Get(ctx, "foo2")
+	assert.True(t, ok)
+
+	cache2.Del(ctx, "foo3")
+	time.Sleep(time.Millisecond * 200)
+	_, ok = cache1.Get(ctx, "foo2")
+	assert.False(t, ok)
+	_, ok = cache1.Get(ctx, "foo3")
+	assert.False(t, ok)
+}
+
+func Test_InvalidationIgnoreSelf(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer mini.Close()
+
+	ctx := context.Background()
+	cache, err := New(time.Second*3, WithInvalidation(redis.New(mini.Addr()), "invalidation-test"))
+	assert.Nil(t, err)
+	defer cache.Close()
+
+	cache.Set(ctx, "foo", "bar")
+	cache.invalidation.handle(`{"from":"` + cache.invalidation.id + `","keys":["foo"]}`)
+	_, ok := cache.Get(ctx, "foo")
+	assert.True(t, ok)
+
+	cache.invalidation.handle(`{"from":"other","keys":["foo"]}`)
+	_, ok = cache.Get(ctx, "foo")
+	assert.False(t, ok)
+}
+
+func Test_InvalidationFlushOnReconnect(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer mini.Close()
+
+	ctx := context.Background()
+	cache, err := New(time.Second*10, WithInvalidation(redis.New(mini.Addr()), "invalidation-test"))
+	assert.Nil(t, err)
+	defer cache.Close()
+
+	time.Sleep(time.Millisecond * 100)
+	cache.Set(ctx, "foo", "bar")
+
+	mini.Close()
+	time.Sleep(time.Millisecond * 100)
+	assert.Nil(t, mini.Restart())
+
+	time.Sleep(invalidationRetryInterval + time.Millisecond*500)
+	_, ok := cache.Get(ctx, "foo")
+	assert.False(t, ok)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/sado0823/go-kitx/kit/localcache/internal"
+	"github.com/sado0823/go-kitx/pkg/syncx"
 
 	"golang.org/x/sync/singleflight"
 )
@@ -20,16 +21,36 @@ type (
 	Cache struct {
 		name   string
 		lock   sync.Mutex
-		data   map[string]interface{}
+		data   map[string]*entry
 		expire time.Duration
 
+		// maxStale keeps expired values around so Take can serve them while refreshing
+		maxStale time.Duration
+		// refreshAhead triggers a background refresh when an entry is about to expire
+		refreshAhead time.Duration
+		// refreshing keys, true if the key is deleted while refreshing
+		refreshing map[string]bool
+
 		lru         internal.Lru
 		timingWheel *internal.TimingWheel
 		sf          *singleflight.Group
 		stat        *internal.Stat
+
+		invalidation *invalidation
 	}
 
 	Option func(cache *Cache)
+
+	entry struct {
+		value    interface{}
+		expireAt time.Time
+	}
+
+	// detachedContext keeps the values of the parent but never gets canceled,
+	// background refresh must outlive the request which triggers it
+	detachedContext struct {
+		context.Context
+	}
 )
 
 func WithName(name string) Option {
@@ -44,12 +65,31 @@ func WithLimit(limit int) Option {
 	}
 }
 
+// WithStaleWhileRevalidate keeps expired entries for at most maxStale,
+// Take returns the stale value immediately and refreshes it in background.
+// if refresh keeps failing, the stale value is evicted once maxStale is reached
+func WithStaleWhileRevalidate(maxStale time.Duration) Option {
+	return func(cache *Cache) {
+		cache.maxStale = maxStale
+	}
+}
+
+// WithRefreshAhead refreshes an entry in background when Take hits it
+// within window of its expiry, so hot keys never expire
+func WithRefreshAhead(window time.Duration) Option {
+	return func(cache *Cache) {
+		cache.refreshAhead = window
+	}
+}
+
+// New create a cache registered in Caches, Close it once it's no longer used
 func New(expire time.Duration, opts ...Option) (cache *Cache, err error) {
 	cache = &Cache{
-		data:   make(map[string]interface{}),
-		expire: expire,
-		lru:    internal.NewNoneLru(),
-		sf:     &singleflight.Group{},
+		data:       make(map[string]*entry),
+		expire:     expire,
+		refreshing: make(map[string]bool),
+		lru:        internal.NewNoneLru(),
+		sf:         &singleflight.Group{},
 	}
 
 	for _, opt := range opts {
@@ -69,20 +109,44 @@ func New(expire time.Duration, opts ...Option) (cache *Cache, err error) {
 			return
 		}
 
-		cache.Del(context.Background(), v)
+		// expiration is local, do not publish it
+		if cache.del(v) {
+			cache.stat.Expire()
+		}
 	})
 	if err != nil {
+		cache.stat.Stop()
 		return nil, err
 	}
 
 	cache.timingWheel = tw
+
+	if cache.invalidation != nil {
+		if err = cache.invalidation.start(); err != nil {
+			tw.Stop()
+			cache.stat.Stop()
+			return nil, err
+		}
+	}
+
+	register(cache)
 	return cache, nil
 }
 
 func (c *Cache) Take(ctx context.Context, key string, fetch func(ctx context.Context) (interface{}, error)) (interface{}, error) {
-	if val, ok := c.doGet(ctx, key); ok {
-		c.stat.Hit()
-		return val, nil
+	if val, expireAt, ok := c.doGetEntry(ctx, key); ok {
+		remain := time.Until(expireAt)
+		if remain > 0 && remain > c.refreshAhead {
+			c.stat.Hit()
+			return val, nil
+		}
+
+		if remain > 0 || c.maxStale > 0 {
+			// about to expire or stale, serve it and refresh in background
+			c.stat.Hit()
+			c.refresh(ctx, key, fetch)
+			return val, nil
+		}
 	}
 
 	var fresh bool
@@ -93,7 +157,7 @@ func (c *Cache) Take(ctx context.Context, key string, fetch func(ctx context.Con
 			return val, nil
 		}
 
-		v, err := fetch(ctx)
+		v, err := c.load(ctx, fetch)
 		if err != nil {
 			return nil, err
 		}
@@ -103,6 +167,8 @@ func (c *Cache) Take(ctx context.Context, key string, fetch func(ctx context.Con
 		return v, nil
 	})
 	if err != nil {
+		// nothing is served from the cache
+		c.stat.Miss()
 		return nil, err
 	}
 
@@ -116,16 +182,30 @@ func (c *Cache) Take(ctx context.Context, key string, fetch func(ctx context.Con
 }
 
 func (c *Cache) Set(_ context.Context, key string, value interface{}) {
+	c.set(key, value, false)
+}
+
+// set stores value, a refresh skips it if the key has been deleted since the refresh started
+func (c *Cache) set(key string, value interface{}, refresh bool) {
 	c.lock.Lock()
+	if refresh && c.refreshing[key] {
+		c.lock.Unlock()
+		return
+	}
 	_, ok := c.data[key]
-	c.data[key] = value
+	c.data[key] = &entry{
+		value:    value,
+		expireAt: time.Now().Add(c.expire),
+	}
 	c.lru.Add(key)
 	c.lock.Unlock()
 
+	// stale entries are only removed after maxStale
+	delay := c.expire + c.maxStale
 	if ok {
-		c.timingWheel.MoveTimer(key, c.expire)
+		c.timingWheel.MoveTimer(key, delay)
 	} else {
-		c.timingWheel.SetTimer(key, value, c.expire)
+		c.timingWheel.SetTimer(key, value, delay)
 	}
 }
 
@@ -140,32 +220,123 @@ func (c *Cache) Get(ctx context.Context, key string) (value interface{}, ok bool
 	return value, ok
 }
 
+// Del removes key, and publishes it to other instances when WithInvalidation is set
 func (c *Cache) Del(_ context.Context, key string) {
+	c.del(key)
+
+	if c.invalidation != nil {
+		c.invalidation.publish(key)
+	}
+}
+
+// Close stops the timing wheel and the invalidation bus, cache can't be used after closed.
+// it must be called once the cache is no longer used, Caches keeps every open cache,
+// so a cache never closed is never garbage collected
+func (c *Cache) Close() {
+	unregister(c)
+	if c.invalidation != nil {
+		c.invalidation.stop()
+	}
+	c.timingWheel.Stop()
+	c.stat.Stop()
+}
+
+// del removes key locally, returns whether the key existed
+func (c *Cache) del(key string) bool {
 	c.lock.Lock()
+	_, ok := c.data[key]
 	delete(c.data, key)
 	c.lru.Remove(key)
+	if _, refreshing := c.refreshing[key]; refreshing {
+		c.refreshing[key] = true
+	}
 	c.lock.Unlock()
 
 	// using chan
 	c.timingWheel.RemoveTimer(key)
+	return ok
+}
+
+func (c *Cache) load(ctx context.Context, fetch func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	start := time.Now()
+	v, err := fetch(ctx)
+	c.stat.Load(time.Since(start), err)
+	return v, err
+}
+
+// doGet only returns fresh values, stale ones are just visible to Take
+func (c *Cache) doGet(ctx context.Context, key string) (value interface{}, ok bool) {
+	value, expireAt, ok := c.doGetEntry(ctx, key)
+	if ok && c.maxStale > 0 && !time.Now().Before(expireAt) {
+		return nil, false
+	}
+
+	return value, ok
 }
 
-func (c *Cache) doGet(_ context.Context, key string) (value interface{}, ok bool) {
+func (c *Cache) doGetEntry(_ context.Context, key string) (value interface{}, expireAt time.Time, ok bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	value, ok = c.data[key]
-	if ok {
-		c.lru.Add(key)
+	e, ok := c.data[key]
+	if !ok {
+		return nil, expireAt, false
 	}
 
-	return value, ok
+	c.lru.Add(key)
+	return e.value, e.expireAt, true
+}
+
+// refresh runs fetch in background, at most one refresh per key at the same time.
+// on failure the old value is kept, it will be evicted by the timing wheel at last
+func (c *Cache) refresh(ctx context.Context, key string, fetch func(ctx context.Context) (interface{}, error)) {
+	c.lock.Lock()
+	if _, ok := c.refreshing[key]; ok {
+		c.lock.Unlock()
+		return
+	}
+	c.refreshing[key] = false
+	c.lock.Unlock()
+
+	ctx = detachedContext{Context: ctx}
+	go syncx.GoSave(func() {
+		defer func() {
+			c.lock.Lock()
+			delete(c.refreshing, key)
+			c.lock.Unlock()
+		}()
+
+		_, _, _ = c.sf.Do(key, func() (interface{}, error) {
+			v, err := c.load(ctx, fetch)
+			if err != nil {
+				return nil, err
+			}
+
+			c.set(key, v, true)
+			return v, nil
+		})
+	})
 }
 
 func (c *Cache) onEvict(key string) {
 	// already locked
 	delete(c.data, key)
 	c.timingWheel.RemoveTimer(key)
+	c.stat.Evict()
+}
+
+// flush removes all keys locally
+func (c *Cache) flush() {
+	c.lock.Lock()
+	keys := make([]string, 0, len(c.data))
+	for key := range c.data {
+		keys = append(keys, key)
+	}
+	c.lock.Unlock()
+
+	for _, key := range keys {
+		c.del(key)
+	}
 }
 
 func (c *Cache) size() int {
@@ -173,3 +344,15 @@ func (c *Cache) size() int {
 	defer c.lock.Unlock()
 	return len(c.data)
 }
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
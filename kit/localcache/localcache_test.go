@@ -211,6 +211,138 @@ func Test_WithLruEvicted(t *testing.T) {
 	assert.True(t, ok)
 }
 
+func Test_TakeStaleWhileRevalidate(t *testing.T) {
+	cache, err := New(time.Millisecond*500, WithStaleWhileRevalidate(time.Second*3))
+	assert.Nil(t, err)
+
+	var (
+		counter int32
+		ctx     = context.Background()
+	)
+
+	cache.Set(ctx, "foo", "bar")
+	time.Sleep(time.Millisecond * 600)
+
+	_, ok := cache.Get(ctx, "foo")
+	assert.False(t, ok)
+
+	for i := 0; i < 10; i++ {
+		value, errN := cache.Take(ctx, "foo", func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&counter, 1)
+			time.Sleep(time.Millisecond * 100)
+			return "bar2", nil
+		})
+		assert.Nil(t, errN)
+		assert.Equal(t, "bar", value)
+	}
+
+	time.Sleep(time.Millisecond * 150)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&counter))
+
+	value, ok := cache.Get(ctx, "foo")
+	assert.True(t, ok)
+	assert.Equal(t, "bar2", value)
+}
+
+func Test_TakeStaleRefreshError(t *testing.T) {
+	cache, err := New(time.Millisecond*100, WithStaleWhileRevalidate(time.Second))
+	assert.Nil(t, err)
+
+	var (
+		ctx     = context.Background()
+		errNoob = errors.New("noob")
+		fetch   = func(ctx context.Context) (interface{}, error) {
+			return nil, errNoob
+		}
+	)
+
+	cache.Set(ctx, "foo", "bar")
+	time.Sleep(time.Millisecond * 200)
+
+	value, errN := cache.Take(ctx, "foo", fetch)
+	assert.Nil(t, errN)
+	assert.Equal(t, "bar", value)
+
+	time.Sleep(time.Millisecond * 100)
+	value, errN = cache.Take(ctx, "foo", fetch)
+	assert.Nil(t, errN)
+	assert.Equal(t, "bar", value)
+
+	// evicted after max stale
+	time.Sleep(time.Second * 2)
+	value, errN = cache.Take(ctx, "foo", fetch)
+	assert.ErrorIs(t, errN, errNoob)
+	assert.Nil(t, value)
+}
+
+func Test_TakeRefreshAhead(t *testing.T) {
+	cache, err := New(time.Second, WithRefreshAhead(time.Millisecond*800))
+	assert.Nil(t, err)
+
+	var (
+		counter int32
+		ctx     = context.Background()
+	)
+
+	cache.Set(ctx, "foo", "bar")
+	time.Sleep(time.Millisecond * 300)
+
+	value, errN := cache.Take(ctx, "foo", func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&counter, 1)
+		return "bar2", nil
+	})
+	assert.Nil(t, errN)
+	assert.Equal(t, "bar", value)
+
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&counter))
+
+	value, ok := cache.Get(ctx, "foo")
+	assert.True(t, ok)
+	assert.Equal(t, "bar2", value)
+}
+
+func Test_TakeRefreshAfterDel(t *testing.T) {
+	cache, err := New(time.Second, WithRefreshAhead(time.Millisecond*800))
+	assert.Nil(t, err)
+	defer cache.Close()
+
+	var (
+		ctx     = context.Background()
+		started = make(chan struct{})
+		release = make(chan struct{})
+		done    = make(chan struct{})
+	)
+
+	cache.Set(ctx, "foo", "bar")
+	time.Sleep(time.Millisecond * 300)
+
+	value, errN := cache.Take(ctx, "foo", func(ctx context.Context) (interface{}, error) {
+		defer close(done)
+		close(started)
+		<-release
+		return "bar2", nil
+	})
+	assert.Nil(t, errN)
+	assert.Equal(t, "bar", value)
+
+	// deleted while refreshing, the refreshed value must not bring it back
+	<-started
+	cache.Del(ctx, "foo")
+	close(release)
+	<-done
+	time.Sleep(time.Millisecond * 50)
+
+	_, ok := cache.Get(ctx, "foo")
+	assert.False(t, ok)
+
+	// refreshing again works once the key is set
+	cache.Set(ctx, "foo", "bar3")
+	value, ok = cache.Get(ctx, "foo")
+	assert.True(t, ok)
+	assert.Equal(t, "bar3", value)
+}
+
 func Benchmark_Cache(b *testing.B) {
 	cache, err := New(time.Second*5, WithLimit(100000))
 	if err != nil {
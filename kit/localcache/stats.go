@@ -0,0 +1,104 @@
+package localcache
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[*Cache]struct{})
+)
+
+// Stats is the cumulative statistics of a Cache since it was created
+type Stats struct {
+	Name string
+	// Size is the count of elements currently cached
+	Size          int
+	Hits          uint64
+	Misses        uint64
+	Evictions     uint64
+	Expirations   uint64
+	LoadSuccesses uint64
+	LoadFailures  uint64
+	// TotalLoadTime is the time spent by all fetches
+	TotalLoadTime time.Duration
+}
+
+// HitRatio return hits / (hits + misses)
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// AverageLoadTime return the mean cost of fetches
+func (s Stats) AverageLoadTime() time.Duration {
+	total := s.LoadSuccesses + s.LoadFailures
+	if total == 0 {
+		return 0
+	}
+	return s.TotalLoadTime / time.Duration(total)
+}
+
+// Stats return statistics of the cache
+func (c *Cache) Stats() Stats {
+	snapshot := c.stat.Snapshot()
+	return Stats{
+		Name:          c.name,
+		Size:          c.size(),
+		Hits:          snapshot.Hit,
+		Misses:        snapshot.Miss,
+		Evictions:     snapshot.Evict,
+		Expirations:   snapshot.Expire,
+		LoadSuccesses: snapshot.LoadSuccess,
+		LoadFailures:  snapshot.LoadFail,
+		TotalLoadTime: snapshot.LoadDuration,
+	}
+}
+
+// Name return the name of the cache
+func (c *Cache) Name() string {
+	return c.name
+}
+
+// Caches return all caches in process which are not closed, sorted by name,
+// caches are held until Close is called
+func Caches() []*Cache {
+	registryLock.RLock()
+	caches := make([]*Cache, 0, len(registry))
+	for cache := range registry {
+		caches = append(caches, cache)
+	}
+	registryLock.RUnlock()
+
+	sort.SliceStable(caches, func(i, j int) bool {
+		return caches[i].name < caches[j].name
+	})
+	return caches
+}
+
+// AllStats return statistics of all caches in process, sorted by name
+func AllStats() []Stats {
+	caches := Caches()
+	stats := make([]Stats, 0, len(caches))
+	for _, cache := range caches {
+		stats = append(stats, cache.Stats())
+	}
+	return stats
+}
+
+func register(cache *Cache) {
+	registryLock.Lock()
+	registry[cache] = struct{}{}
+	registryLock.Unlock()
+}
+
+func unregister(cache *Cache) {
+	registryLock.Lock()
+	delete(registry, cache)
+	registryLock.Unlock()
+}
@@ -0,0 +1,72 @@
+package localcache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Stats(t *testing.T) {
+	cache, err := New(time.Second, WithName("stats"), WithLimit(2))
+	assert.Nil(t, err)
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	_, err = cache.Take(ctx, "foo1", func(ctx context.Context) (interface{}, error) {
+		time.Sleep(time.Millisecond * 10)
+		return "bar1", nil
+	})
+	assert.Nil(t, err)
+	_, err = cache.Take(ctx, "foo1", func(ctx context.Context) (interface{}, error) {
+		return "bar1", nil
+	})
+	assert.Nil(t, err)
+	_, err = cache.Take(ctx, "foo2", func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("noob")
+	})
+	assert.NotNil(t, err)
+
+	cache.Set(ctx, "foo3", "bar3")
+	cache.Set(ctx, "foo4", "bar4")
+
+	stats := cache.Stats()
+	assert.Equal(t, "stats", stats.Name)
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, uint64(1), stats.Hits)
+	// the failed load is a miss too
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, uint64(1), stats.LoadSuccesses)
+	assert.Equal(t, uint64(1), stats.LoadFailures)
+	assert.InDelta(t, 1.0/3, stats.HitRatio(), 1e-9)
+	assert.True(t, stats.TotalLoadTime >= time.Millisecond*10)
+	assert.True(t, stats.AverageLoadTime() >= time.Millisecond*5)
+
+	time.Sleep(time.Second * 3)
+	stats = cache.Stats()
+	assert.Equal(t, 0, stats.Size)
+	assert.Equal(t, uint64(2), stats.Expirations)
+}
+
+func Test_Caches(t *testing.T) {
+	cache1, err := New(time.Second, WithName("caches-b"))
+	assert.Nil(t, err)
+	cache2, err := New(time.Second, WithName("caches-a"))
+	assert.Nil(t, err)
+	defer cache2.Close()
+
+	var names []string
+	for _, stats := range AllStats() {
+		names = append(names, stats.Name)
+	}
+	assert.Contains(t, names, "caches-a")
+	assert.Contains(t, names, "caches-b")
+
+	cache1.Close()
+	assert.NotContains(t, Caches(), cache1)
+	assert.Contains(t, Caches(), cache2)
+}
@@ -46,6 +46,11 @@ type (
 		MarkFail()
 	}
 
+	// Limiter admits a request or not, the returned Promise must be marked when request is done
+	Limiter interface {
+		Allow() (Promise, error)
+	}
+
 	promise struct {
 		startTime time.Duration
 		bbr       *BBR
@@ -65,6 +70,8 @@ type (
 	}
 )
 
+var _ Limiter = (*BBR)(nil)
+
 func WithBBRWindow(window time.Duration) BBROptionFn {
 	return func(option *bbrOption) {
 		option.window = window
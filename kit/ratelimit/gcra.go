@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/store/redis"
+)
+
+// KEYS[1] theoretical arrival time in milliseconds
+// return {allowed, remaining, retry_after_ms, reset_after_ms}
+const gcraScript = `local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+local cost = tonumber(ARGV[5])
+local emission_interval = period / rate
+local increment = emission_interval * cost
+local burst_offset = emission_interval * burst
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if tat == nil then
+	tat = now
+end
+tat = math.max(tat, now)
+
+local new_tat = tat + increment
+local allow_at = new_tat - burst_offset
+local diff = now - allow_at
+if diff < 0 then
+	return {0, 0, math.ceil(-diff), math.ceil(tat - now)}
+end
+
+local reset_after = new_tat - now
+redis.call("SET", KEYS[1], new_tat, "PX", math.ceil(reset_after))
+return {1, math.floor(diff / emission_interval), 0, math.ceil(reset_after)}`
+
+type (
+	GCRAOption func(g *GCRA)
+
+	// GCRA is the generic cell rate algorithm with redis, it's a token bucket
+	// which keeps a single timestamp per key, requests are spread evenly and burst is allowed
+	GCRA struct {
+		rate      int
+		period    time.Duration
+		burst     int
+		store     *redis.Redis
+		keyPrefix string
+	}
+)
+
+var _ QuotaLimiter = (*GCRA)(nil)
+
+func WithGCRAPrefix(prefix string) GCRAOption {
+	return func(g *GCRA) {
+		g.keyPrefix = prefix
+	}
+}
+
+// NewGCRA allows rate requests per period, with at most burst requests at once
+func NewGCRA(rate int, period time.Duration, burst int, store *redis.Redis, options ...GCRAOption) *GCRA {
+	if rate <= 0 || period < time.Millisecond || burst <= 0 || store == nil {
+		panic("invalid gcra param")
+	}
+
+	limiter := &GCRA{
+		rate:   rate,
+		period: period,
+		burst:  burst,
+		store:  store,
+	}
+	for _, option := range options {
+		option(limiter)
+	}
+	return limiter
+}
+
+func (g *GCRA) Take(ctx context.Context, key string) (*Quota, error) {
+	return g.TakeN(ctx, key, 1)
+}
+
+func (g *GCRA) TakeN(ctx context.Context, key string, n int) (*Quota, error) {
+	resp, err := g.store.Eval(ctx, gcraScript, []string{g.keyPrefix + key}, []string{
+		strconv.Itoa(g.burst),
+		strconv.Itoa(g.rate),
+		strconv.FormatInt(int64(g.period/time.Millisecond), 10),
+		strconv.FormatInt(unixMilli(time.Now()), 10),
+		strconv.Itoa(n),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseQuota(resp, g.burst)
+}
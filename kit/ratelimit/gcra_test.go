@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/store/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCRA_Take(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer mini.Close()
+
+	var (
+		ctx = context.Background()
+		// a request per 100ms, burst 3
+		limiter = NewGCRA(10, time.Second, 3, redis.New(mini.Addr()), WithGCRAPrefix("gcra:"))
+	)
+
+	for i := 0; i < 3; i++ {
+		quota, err := limiter.Take(ctx, "foo")
+		assert.Nil(t, err)
+		assert.True(t, quota.Allowed)
+		assert.Equal(t, 3, quota.Limit)
+		assert.Equal(t, 2-i, quota.Remaining)
+	}
+
+	quota, err := limiter.Take(ctx, "foo")
+	assert.Nil(t, err)
+	assert.False(t, quota.Allowed)
+	assert.True(t, quota.RetryAfter > 0 && quota.RetryAfter <= time.Millisecond*100)
+	assert.True(t, quota.ResetAfter > time.Millisecond*200 && quota.ResetAfter <= time.Millisecond*300)
+
+	time.Sleep(quota.RetryAfter)
+	quota, err = limiter.Take(ctx, "foo")
+	assert.Nil(t, err)
+	assert.True(t, quota.Allowed)
+	assert.Equal(t, 0, quota.Remaining)
+
+	quota, err = limiter.TakeN(ctx, "bar", 4)
+	assert.Nil(t, err)
+	assert.False(t, quota.Allowed)
+	quota, err = limiter.TakeN(ctx, "bar", 3)
+	assert.Nil(t, err)
+	assert.True(t, quota.Allowed)
+	assert.True(t, mini.Exists("gcra:bar"))
+}
@@ -0,0 +1,221 @@
+package ratelimit
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/log"
+	rollingwindow "github.com/sado0823/go-kitx/pkg/rollingwindow/v2"
+	"github.com/sado0823/go-kitx/pkg/timex"
+)
+
+const (
+	gradientBuckets      = 10
+	gradientWindow       = time.Second
+	gradientInitialLimit = 20
+	gradientMinLimit     = 10
+	gradientMaxLimit     = 1000
+	gradientSmoothing    = 0.2
+	gradientTolerance    = 1.5
+	gradientLongWindow   = 600
+	// long rtt is reset towards short rtt when it drifts too far away
+	gradientDriftRatio = 2
+	gradientDriftDecay = 0.95
+	gradientMinGrad    = 0.5
+)
+
+var ErrGradientServiceOverload = errors.New("service overload with gradient limiter")
+
+type (
+	GradientOptionFn func(*gradientOption)
+
+	gradientOption struct {
+		window       time.Duration
+		buckets      int
+		initialLimit int64
+		minLimit     int64
+		maxLimit     int64
+		smoothing    float64
+		tolerance    float64
+		longWindow   int
+	}
+
+	gradientPromise struct {
+		startTime time.Duration
+		limiter   *Gradient
+	}
+
+	// Gradient is an adaptive concurrency limiter like netflix Gradient2,
+	// the limit grows while the latency of short window keeps close to the long term one,
+	// and shrinks when requests queue up downstream and latency rises.
+	// it suits for I/O bound services, which are not busy on cpu while overloaded
+	Gradient struct {
+		option *gradientOption
+		flying int64
+		limit  int64
+
+		lock       sync.Mutex
+		rtCounter  *rollingwindow.RollingWindow
+		longRt     float64
+		longCount  int
+		lastUpdate time.Duration
+	}
+)
+
+var _ Limiter = (*Gradient)(nil)
+
+// WithGradientWindow set the window which short term latency is sampled in, the limit is updated once a bucket,
+// buckets must be positive and window must be at least buckets nanoseconds
+func WithGradientWindow(window time.Duration, buckets int) GradientOptionFn {
+	return func(option *gradientOption) {
+		option.window = window
+		option.buckets = buckets
+	}
+}
+
+// WithGradientLimit set the initial, minimum and maximum concurrency
+func WithGradientLimit(initial, min, max int) GradientOptionFn {
+	return func(option *gradientOption) {
+		option.initialLimit = int64(initial)
+		option.minLimit = int64(min)
+		option.maxLimit = int64(max)
+	}
+}
+
+// WithGradientSmoothing set how fast the limit moves to the new one, in (0, 1]
+func WithGradientSmoothing(smoothing float64) GradientOptionFn {
+	return func(option *gradientOption) {
+		option.smoothing = smoothing
+	}
+}
+
+// WithGradientTolerance set how much short term latency could exceed the long term one before shrinking
+func WithGradientTolerance(tolerance float64) GradientOptionFn {
+	return func(option *gradientOption) {
+		option.tolerance = tolerance
+	}
+}
+
+func NewGradient(options ...GradientOptionFn) *Gradient {
+	op := &gradientOption{
+		window:       gradientWindow,
+		buckets:      gradientBuckets,
+		initialLimit: gradientInitialLimit,
+		minLimit:     gradientMinLimit,
+		maxLimit:     gradientMaxLimit,
+		smoothing:    gradientSmoothing,
+		tolerance:    gradientTolerance,
+		longWindow:   gradientLongWindow,
+	}
+
+	for _, option := range options {
+		option(op)
+	}
+	if op.buckets <= 0 || op.window < time.Duration(op.buckets) {
+		panic("invalid gradient window param")
+	}
+
+	return &Gradient{
+		option:     op,
+		limit:      op.initialLimit,
+		rtCounter:  rollingwindow.New(op.buckets, op.window/time.Duration(op.buckets)),
+		lastUpdate: timex.Now(),
+	}
+}
+
+func (p *gradientPromise) MarkSuccess() {
+	atomic.AddInt64(&p.limiter.flying, -1)
+	p.limiter.sample(timex.Since(p.startTime))
+}
+
+func (p *gradientPromise) MarkFail() {
+	atomic.AddInt64(&p.limiter.flying, -1)
+}
+
+func (g *Gradient) Allow() (Promise, error) {
+	if flying := atomic.AddInt64(&g.flying, 1); flying > atomic.LoadInt64(&g.limit) {
+		atomic.AddInt64(&g.flying, -1)
+		return nil, ErrGradientServiceOverload
+	}
+
+	return &gradientPromise{
+		startTime: timex.Now(),
+		limiter:   g,
+	}, nil
+}
+
+// Limit return current concurrency limit
+func (g *Gradient) Limit() int64 {
+	return atomic.LoadInt64(&g.limit)
+}
+
+func (g *Gradient) sample(rt time.Duration) {
+	g.rtCounter.Add(float64(rt) / float64(time.Millisecond))
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	now := timex.Now()
+	if now-g.lastUpdate < g.option.window/time.Duration(g.option.buckets) {
+		return
+	}
+	g.lastUpdate = now
+	g.update()
+}
+
+// update must be called with lock
+func (g *Gradient) update() {
+	shortRt := g.shortRt()
+	if shortRt <= 0 {
+		return
+	}
+
+	// exponential average of short rtt, with a warmup of plain average
+	if g.longCount < g.option.longWindow {
+		g.longCount++
+		g.longRt += (shortRt - g.longRt) / float64(g.longCount)
+	} else {
+		factor := 2 / float64(g.option.longWindow+1)
+		g.longRt = g.longRt*(1-factor) + shortRt*factor
+	}
+
+	// recover quickly from a long period of high latency
+	if g.longRt/shortRt > gradientDriftRatio {
+		g.longRt *= gradientDriftDecay
+	}
+
+	limit := atomic.LoadInt64(&g.limit)
+	// app limited, there is no evidence the limit is too small
+	if atomic.LoadInt64(&g.flying) < limit/2 && g.longRt >= shortRt {
+		return
+	}
+
+	gradient := math.Max(gradientMinGrad, math.Min(1, g.option.tolerance*g.longRt/shortRt))
+	queueSize := math.Sqrt(float64(limit))
+	newLimit := float64(limit)*gradient + queueSize
+	newLimit = float64(limit)*(1-g.option.smoothing) + newLimit*g.option.smoothing
+	newLimit = math.Max(float64(g.option.minLimit), math.Min(float64(g.option.maxLimit), newLimit))
+
+	if next := int64(math.Round(newLimit)); next != limit {
+		log.Debugf("gradient limit: %d -> %d, shortRt: %.2f, longRt: %.2f", limit, next, shortRt, g.longRt)
+		atomic.StoreInt64(&g.limit, next)
+	}
+}
+
+func (g *Gradient) shortRt() float64 {
+	var (
+		sum   float64
+		count int64
+	)
+	g.rtCounter.Reduce(func(bucket *rollingwindow.Bucket) {
+		sum += bucket.Sum
+		count += bucket.Count
+	})
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Gradient_Allow(t *testing.T) {
+	g := NewGradient(WithGradientLimit(2, 1, 10))
+
+	p1, err := g.Allow()
+	assert.Nil(t, err)
+	p2, err := g.Allow()
+	assert.Nil(t, err)
+
+	_, err = g.Allow()
+	assert.ErrorIs(t, err, ErrGradientServiceOverload)
+
+	p1.MarkSuccess()
+	p3, err := g.Allow()
+	assert.Nil(t, err)
+
+	p2.MarkFail()
+	p3.MarkSuccess()
+	assert.EqualValues(t, 0, atomic.LoadInt64(&g.flying))
+}
+
+func Test_Gradient_InvalidWindow(t *testing.T) {
+	assert.Panics(t, func() { NewGradient(WithGradientWindow(time.Second, 0)) })
+	assert.Panics(t, func() { NewGradient(WithGradientWindow(time.Second, -1)) })
+	assert.Panics(t, func() { NewGradient(WithGradientWindow(0, 10)) })
+	assert.NotPanics(t, func() { NewGradient(WithGradientWindow(time.Second, 1)) })
+}
+
+func Test_Gradient_Adjust(t *testing.T) {
+	g := NewGradient(
+		WithGradientWindow(time.Millisecond*100, 10),
+		WithGradientLimit(20, 5, 100),
+		WithGradientSmoothing(0.5),
+	)
+
+	feed := func(rt time.Duration, rounds int) {
+		for i := 0; i < rounds; i++ {
+			// keep the limiter busy, or it's app limited
+			atomic.StoreInt64(&g.flying, g.Limit())
+			for j := 0; j < 10; j++ {
+				g.sample(rt)
+			}
+			time.Sleep(time.Millisecond * 12)
+		}
+		atomic.StoreInt64(&g.flying, 0)
+	}
+
+	feed(time.Millisecond*10, 20)
+	grown := g.Limit()
+	assert.Greater(t, grown, int64(20))
+
+	feed(time.Millisecond*100, 20)
+	assert.Less(t, g.Limit(), grown)
+}
+
+func Test_Gradient_AppLimited(t *testing.T) {
+	g := NewGradient(WithGradientWindow(time.Millisecond*100, 10), WithGradientLimit(20, 5, 100))
+
+	for i := 0; i < 20; i++ {
+		g.sample(time.Millisecond * 10)
+		time.Sleep(time.Millisecond * 12)
+	}
+	assert.EqualValues(t, 20, g.Limit())
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/localcache"
+)
+
+const (
+	keyedLimit  = 10000
+	keyedExpire = time.Minute
+	keyedName   = "ratelimit-keyed"
+)
+
+type (
+	KeyedOptionFn func(*keyedOption)
+
+	keyedOption struct {
+		limit  int
+		expire time.Duration
+		name   string
+	}
+
+	// Keyed limits per key, e.g. tenant or ip, each key has its own LocalLimiter.
+	// keys are kept in a localcache, so the least recently used ones are evicted when there are too many,
+	// and idle ones expire, unbounded keys can't leak memory
+	Keyed struct {
+		option  *keyedOption
+		cache   *localcache.Cache
+		factory func() LocalLimiter
+	}
+
+	keyedEntry struct {
+		limiter LocalLimiter
+		touched time.Time
+	}
+)
+
+// WithKeyedLimit set the max count of keys
+func WithKeyedLimit(limit int) KeyedOptionFn {
+	return func(option *keyedOption) {
+		option.limit = limit
+	}
+}
+
+// WithKeyedExpire set how long an idle key is kept, it should be longer than the window of limiter
+func WithKeyedExpire(expire time.Duration) KeyedOptionFn {
+	return func(option *keyedOption) {
+		option.expire = expire
+	}
+}
+
+// WithKeyedName set the name of underlying localcache
+func WithKeyedName(name string) KeyedOptionFn {
+	return func(option *keyedOption) {
+		option.name = name
+	}
+}
+
+func NewKeyed(factory func() LocalLimiter, options ...KeyedOptionFn) (*Keyed, error) {
+	op := &keyedOption{
+		limit:  keyedLimit,
+		expire: keyedExpire,
+		name:   keyedName,
+	}
+	for _, option := range options {
+		option(op)
+	}
+
+	cache, err := localcache.New(op.expire, localcache.WithName(op.name), localcache.WithLimit(op.limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Keyed{
+		option:  op,
+		cache:   cache,
+		factory: factory,
+	}, nil
+}
+
+func (k *Keyed) Allow(key string) bool {
+	return k.get(key).Allow()
+}
+
+func (k *Keyed) AllowN(key string, now time.Time, n int) bool {
+	return k.get(key).AllowN(now, n)
+}
+
+func (k *Keyed) Wait(ctx context.Context, key string) error {
+	return k.get(key).Wait(ctx)
+}
+
+// Close release the underlying localcache
+func (k *Keyed) Close() {
+	k.cache.Close()
+}
+
+func (k *Keyed) get(key string) LocalLimiter {
+	ctx := context.Background()
+	val, _ := k.cache.Take(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return &keyedEntry{limiter: k.factory(), touched: time.Now()}, nil
+	})
+
+	entry := val.(*keyedEntry)
+	// expiration of localcache starts from Set, renew it for active keys,
+	// at most once per half of expire to keep the timing wheel quiet
+	if now := time.Now(); now.Sub(entry.touched) > k.option.expire/2 {
+		k.cache.Set(ctx, key, &keyedEntry{limiter: entry.limiter, touched: now})
+	}
+
+	return entry.limiter
+}
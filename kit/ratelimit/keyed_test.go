@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyed(t *testing.T) {
+	keyed, err := NewKeyed(func() LocalLimiter {
+		return NewLocalSlidingWindow(time.Minute, 2)
+	}, WithKeyedLimit(2), WithKeyedName("keyed-test"))
+	assert.Nil(t, err)
+	defer keyed.Close()
+
+	assert.True(t, keyed.Allow("foo"))
+	assert.True(t, keyed.Allow("foo"))
+	assert.False(t, keyed.Allow("foo"))
+
+	// keys are limited independently
+	assert.True(t, keyed.AllowN("bar", time.Now(), 2))
+	assert.False(t, keyed.Allow("bar"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.NotNil(t, keyed.Wait(ctx, "bar"))
+
+	// foo is the least recently used one, evicted and reset
+	assert.True(t, keyed.Allow("baz"))
+	assert.True(t, keyed.Allow("foo"))
+}
+
+func TestKeyed_Expire(t *testing.T) {
+	keyed, err := NewKeyed(func() LocalLimiter {
+		return NewLocalToken(1, 1)
+	}, WithKeyedExpire(time.Second))
+	assert.Nil(t, err)
+	defer keyed.Close()
+
+	assert.True(t, keyed.Allow("foo"))
+	assert.False(t, keyed.Allow("foo"))
+
+	time.Sleep(time.Second * 2)
+	assert.Equal(t, 0, keyed.cache.Stats().Size)
+}
@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	xrate "golang.org/x/time/rate"
+)
+
+type (
+	// LocalLimiter is an in-process limiter, which works without redis
+	LocalLimiter interface {
+		Allow() bool
+		AllowN(now time.Time, n int) bool
+		// Wait blocks until a request is allowed or ctx is done
+		Wait(ctx context.Context) error
+	}
+
+	// LocalToken is an in-process token bucket, rate tokens are put into the bucket per second
+	LocalToken struct {
+		limiter *xrate.Limiter
+	}
+
+	// LocalSlidingWindow is an in-process sliding window log,
+	// at most quota requests are allowed in any window
+	LocalSlidingWindow struct {
+		window time.Duration
+		quota  int
+
+		lock sync.Mutex
+		// logs is a ring buffer of the allowed requests' time
+		logs  []time.Time
+		start int
+		size  int
+	}
+)
+
+var (
+	_ LocalLimiter = (*LocalToken)(nil)
+	_ LocalLimiter = (*LocalSlidingWindow)(nil)
+)
+
+func NewLocalToken(rate, capacity int) *LocalToken {
+	if rate <= 0 || capacity <= 0 {
+		panic("invalid local token param")
+	}
+
+	return &LocalToken{
+		limiter: xrate.NewLimiter(xrate.Limit(rate), capacity),
+	}
+}
+
+func (t *LocalToken) Allow() bool {
+	return t.AllowN(time.Now(), 1)
+}
+
+func (t *LocalToken) AllowN(now time.Time, n int) bool {
+	return t.limiter.AllowN(now, n)
+}
+
+func (t *LocalToken) Wait(ctx context.Context) error {
+	return t.limiter.Wait(ctx)
+}
+
+func NewLocalSlidingWindow(window time.Duration, quota int) *LocalSlidingWindow {
+	if window <= 0 || quota <= 0 {
+		panic("invalid local sliding window param")
+	}
+
+	return &LocalSlidingWindow{
+		window: window,
+		quota:  quota,
+		logs:   make([]time.Time, quota),
+	}
+}
+
+func (w *LocalSlidingWindow) Allow() bool {
+	return w.AllowN(time.Now(), 1)
+}
+
+func (w *LocalSlidingWindow) AllowN(now time.Time, n int) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	_, ok := w.reserveN(now, n)
+	return ok
+}
+
+func (w *LocalSlidingWindow) Wait(ctx context.Context) error {
+	for {
+		w.lock.Lock()
+		delay, ok := w.reserveN(time.Now(), 1)
+		w.lock.Unlock()
+		if ok {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserveN must be called with lock, returns how long to wait for if not allowed
+func (w *LocalSlidingWindow) reserveN(now time.Time, n int) (time.Duration, bool) {
+	if n > w.quota {
+		return w.window, false
+	}
+
+	// drop the logs out of window
+	boundary := now.Add(-w.window)
+	for w.size > 0 && !w.logs[w.start].After(boundary) {
+		w.start = (w.start + 1) % w.quota
+		w.size--
+	}
+
+	if w.size+n > w.quota {
+		// wait until enough logs slide out
+		oldest := w.logs[(w.start+w.size+n-w.quota-1)%w.quota]
+		return oldest.Sub(boundary), false
+	}
+
+	for i := 0; i < n; i++ {
+		w.logs[(w.start+w.size)%w.quota] = now
+		w.size++
+	}
+	return 0, true
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalToken(t *testing.T) {
+	token := NewLocalToken(10, 5)
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		assert.True(t, token.AllowN(now, 1))
+	}
+	assert.False(t, token.AllowN(now, 1))
+	// 10 tokens per second
+	assert.True(t, token.AllowN(now.Add(time.Millisecond*100), 1))
+	assert.False(t, token.AllowN(now.Add(time.Millisecond*100), 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.NotNil(t, token.Wait(ctx))
+	assert.Nil(t, token.Wait(context.Background()))
+}
+
+func TestLocalSlidingWindow(t *testing.T) {
+	window := NewLocalSlidingWindow(time.Second, 3)
+	now := time.Now()
+
+	assert.True(t, window.AllowN(now, 2))
+	assert.True(t, window.AllowN(now.Add(time.Millisecond*500), 1))
+	assert.False(t, window.AllowN(now.Add(time.Millisecond*900), 1))
+	assert.False(t, window.AllowN(now, 4))
+
+	// no boundary to double the quota, the first two slide out
+	assert.True(t, window.AllowN(now.Add(time.Second), 2))
+	assert.False(t, window.AllowN(now.Add(time.Millisecond*1200), 1))
+	assert.True(t, window.AllowN(now.Add(time.Millisecond*1500), 1))
+}
+
+func TestLocalSlidingWindow_Wait(t *testing.T) {
+	window := NewLocalSlidingWindow(time.Millisecond*100, 1)
+	assert.True(t, window.Allow())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.ErrorIs(t, window.Wait(ctx), context.DeadlineExceeded)
+
+	start := time.Now()
+	assert.Nil(t, window.Wait(context.Background()))
+	assert.True(t, time.Since(start) >= time.Millisecond*80)
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrUnknownQuotaResp = errors.New("unknown quota response")
+
+type (
+	// Quota is the result of taking from a QuotaLimiter,
+	// it carries enough to fill X-RateLimit-* and Retry-After headers
+	Quota struct {
+		Allowed bool
+		// Limit is the max requests allowed in a window or burst
+		Limit int
+		// Remaining requests could be taken right now
+		Remaining int
+		// RetryAfter is how long to wait for the next request to be allowed, 0 if allowed
+		RetryAfter time.Duration
+		// ResetAfter is how long until the limiter is fully reset
+		ResetAfter time.Duration
+	}
+
+	// QuotaLimiter limits requests per key and reports the quota left
+	QuotaLimiter interface {
+		Take(ctx context.Context, key string) (*Quota, error)
+	}
+)
+
+func parseQuota(resp interface{}, limit int) (*Quota, error) {
+	values, ok := resp.([]interface{})
+	if !ok || len(values) != 4 {
+		return nil, ErrUnknownQuotaResp
+	}
+
+	nums := make([]int64, len(values))
+	for i, v := range values {
+		if nums[i], ok = v.(int64); !ok {
+			return nil, ErrUnknownQuotaResp
+		}
+	}
+
+	return &Quota{
+		Allowed:    nums[0] == 1,
+		Limit:      limit,
+		Remaining:  int(nums[1]),
+		RetryAfter: time.Duration(nums[2]) * time.Millisecond,
+		ResetAfter: time.Duration(nums[3]) * time.Millisecond,
+	}, nil
+}
+
+func unixMilli(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
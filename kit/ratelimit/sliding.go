@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/store/redis"
+	"github.com/sado0823/go-kitx/pkg/stringx"
+)
+
+// KEYS[1] sorted set of requests in window, scored by milliseconds
+// return {allowed, remaining, retry_after_ms, reset_after_ms}
+const slidingScript = `local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now-window)
+local count = redis.call("ZCARD", KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call("PEXPIRE", KEYS[1], window)
+
+local retry_after = 0
+if allowed == 0 then
+	local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+	retry_after = tonumber(oldest[2]) + window - now
+end
+
+local reset_after = 0
+local newest = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+if #newest > 0 then
+	reset_after = tonumber(newest[2]) + window - now
+end
+
+return {allowed, limit-count, retry_after, reset_after}`
+
+type (
+	SlidingOption func(s *Sliding)
+
+	// Sliding is a sliding window log limiter with redis sorted set,
+	// unlike Period, the quota can't be doubled across a window boundary
+	Sliding struct {
+		window    time.Duration
+		quota     int
+		store     *redis.Redis
+		keyPrefix string
+	}
+)
+
+var _ QuotaLimiter = (*Sliding)(nil)
+
+func WithSlidingPrefix(prefix string) SlidingOption {
+	return func(s *Sliding) {
+		s.keyPrefix = prefix
+	}
+}
+
+func NewSliding(window time.Duration, quota int, store *redis.Redis, options ...SlidingOption) *Sliding {
+	if window < time.Millisecond || quota <= 0 || store == nil {
+		panic("invalid sliding param")
+	}
+
+	limiter := &Sliding{
+		window: window,
+		quota:  quota,
+		store:  store,
+	}
+	for _, option := range options {
+		option(limiter)
+	}
+	return limiter
+}
+
+func (s *Sliding) Take(ctx context.Context, key string) (*Quota, error) {
+	now := unixMilli(time.Now())
+	resp, err := s.store.Eval(ctx, slidingScript, []string{s.keyPrefix + key}, []string{
+		strconv.FormatInt(now, 10),
+		strconv.FormatInt(int64(s.window/time.Millisecond), 10),
+		strconv.Itoa(s.quota),
+		strconv.FormatInt(now, 10) + "-" + stringx.Rand(8),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseQuota(resp, s.quota)
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/store/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSliding_Take(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer mini.Close()
+
+	var (
+		ctx     = context.Background()
+		window  = time.Millisecond * 500
+		limiter = NewSliding(window, 3, redis.New(mini.Addr()), WithSlidingPrefix("sliding:"))
+	)
+
+	for i := 0; i < 3; i++ {
+		quota, err := limiter.Take(ctx, "foo")
+		assert.Nil(t, err)
+		assert.True(t, quota.Allowed)
+		assert.Equal(t, 3, quota.Limit)
+		assert.Equal(t, 2-i, quota.Remaining)
+		assert.Equal(t, time.Duration(0), quota.RetryAfter)
+		assert.True(t, quota.ResetAfter > 0 && quota.ResetAfter <= window)
+	}
+
+	quota, err := limiter.Take(ctx, "foo")
+	assert.Nil(t, err)
+	assert.False(t, quota.Allowed)
+	assert.Equal(t, 0, quota.Remaining)
+	assert.True(t, quota.RetryAfter > 0 && quota.RetryAfter <= window)
+
+	// other keys are not affected
+	quota, err = limiter.Take(ctx, "bar")
+	assert.Nil(t, err)
+	assert.True(t, quota.Allowed)
+
+	time.Sleep(window)
+	quota, err = limiter.Take(ctx, "foo")
+	assert.Nil(t, err)
+	assert.True(t, quota.Allowed)
+	assert.Equal(t, 2, quota.Remaining)
+	assert.True(t, mini.Exists("sliding:foo"))
+}
+
+func TestSliding_StoreErr(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.Nil(t, err)
+
+	limiter := NewSliding(time.Second, 3, redis.New(mini.Addr()))
+	mini.Close()
+
+	quota, err := limiter.Take(context.Background(), "foo")
+	assert.NotNil(t, err)
+	assert.Nil(t, quota)
+}
@@ -18,12 +18,16 @@ import (
 const (
 	// KEYS[1] token_key
 	// KEYS[2] timestamp_key
+	// ARGV[3] now in milliseconds
+	// ARGV[5] max milliseconds to wait for, tokens are reserved in advance if needed
+	// return milliseconds to wait for, -1 if not allowed
 	tokenScript = `local rate = tonumber(ARGV[1])
 local capacity = tonumber(ARGV[2])
 local now = tonumber(ARGV[3])
 local requested = tonumber(ARGV[4])
+local max_wait = tonumber(ARGV[5])
 local fill_time = capacity/rate
-local ttl = math.floor(fill_time*2)
+local ttl = math.max(1, math.ceil(fill_time*2+max_wait/1000))
 local last_tokens = tonumber(redis.call("get",KEYS[1]))
 if last_tokens == nil then
 	last_tokens = capacity
@@ -35,23 +39,33 @@ if last_refreshed == nil then
 end
 
 local delta = math.max(0,now-last_refreshed)
-local filled_tokens = math.min(capacity,(delta*rate)+last_tokens)
-local allowed = filled_tokens >= requested
-local new_tokens = filled_tokens
-if allowed then
-	new_tokens = filled_tokens - requested
+local filled_tokens = math.min(capacity,(delta*rate/1000)+last_tokens)
+local wait = 0
+if filled_tokens < requested then
+	wait = math.ceil((requested-filled_tokens)*1000/rate)
+end
+if wait > max_wait then
+	return -1
 end
 
-redis.call("setex", KEYS[1], ttl, new_tokens)
+redis.call("setex", KEYS[1], ttl, filled_tokens-requested)
 redis.call("setex", KEYS[2], ttl, now)
 
-return allowed`
+return wait`
 
-	tokenFormat          = "{%s}.token"
-	tokenTimestampFormat = "{%s}.timestamp"
-	tokenPingInterval    = time.Microsecond * 100
+	// keys are versioned with the script, v2 keeps fractional tokens and millisecond timestamps,
+	// so instances of different versions never read each other's values
+	tokenFormat          = "{%s}.v2.token"
+	tokenTimestampFormat = "{%s}.v2.timestamp"
+	tokenPingInterval    = time.Millisecond * 100
+	// tokenMaxWait is used when ctx of Wait has no deadline
+	tokenMaxWait = time.Hour
 )
 
+var ErrTokenExceedsDeadline = errors.New("token wait would exceed context deadline")
+
+type TokenOptionFn func(*Token)
+
 type Token struct {
 	rate         int
 	capacity     int
@@ -62,9 +76,20 @@ type Token struct {
 	storeAlive   uint32
 	degrade      *xrate.Limiter
 	monitorOn    bool
+	pingInterval time.Duration
+}
+
+// WithTokenProbeInterval set how often redis is probed while falling back to in-process limiter
+func WithTokenProbeInterval(interval time.Duration) TokenOptionFn {
+	return func(t *Token) {
+		t.pingInterval = interval
+	}
 }
 
-func NewToken(rate, capacity int, store *redis.Redis, key string) *Token {
+// NewToken is a token bucket limiter shared by all instances with redis,
+// when redis is unreachable, it falls back to an in-process limiter with the same rate,
+// and switches back once redis is probed healthy
+func NewToken(rate, capacity int, store *redis.Redis, key string, options ...TokenOptionFn) *Token {
 	if rate <= 0 || capacity <= 0 || key == "" || store == nil {
 		panic("invalid token param")
 	}
@@ -72,7 +97,7 @@ func NewToken(rate, capacity int, store *redis.Redis, key string) *Token {
 	tokenKey := fmt.Sprintf(tokenFormat, key)
 	timestampKey := fmt.Sprintf(tokenTimestampFormat, key)
 
-	return &Token{
+	token := &Token{
 		rate:         rate,
 		capacity:     capacity,
 		store:        store,
@@ -80,7 +105,13 @@ func NewToken(rate, capacity int, store *redis.Redis, key string) *Token {
 		timestampKey: timestampKey,
 		storeAlive:   1,
 		degrade:      xrate.NewLimiter(xrate.Every(time.Second/time.Duration(rate)), capacity),
+		pingInterval: tokenPingInterval,
+	}
+	for _, option := range options {
+		option(token)
 	}
+
+	return token
 }
 
 func (t *Token) Allow(ctx context.Context) bool {
@@ -88,19 +119,65 @@ func (t *Token) Allow(ctx context.Context) bool {
 }
 
 func (t *Token) AllowN(ctx context.Context, now time.Time, n int) bool {
-	return t.reserveN(ctx, now, n)
+	_, ok := t.reserveN(ctx, now, n, 0)
+	return ok
+}
+
+// ReserveN takes n tokens in advance if they are available within maxWait,
+// returns how long the caller should wait for before acting.
+// reserved tokens are not returned even if the caller gives up
+func (t *Token) ReserveN(ctx context.Context, now time.Time, n int, maxWait time.Duration) (time.Duration, bool) {
+	return t.reserveN(ctx, now, n, maxWait)
 }
 
-func (t *Token) reserveN(ctx context.Context, now time.Time, n int) bool {
+// Wait blocks until a token is available or ctx is done
+func (t *Token) Wait(ctx context.Context) error {
+	return t.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n tokens are available, it fails at once if they can't be available before the deadline of ctx
+func (t *Token) WaitN(ctx context.Context, n int) error {
+	now := time.Now()
+	maxWait := tokenMaxWait
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = deadline.Sub(now)
+	}
+
+	delay, ok := t.reserveN(ctx, now, n, maxWait)
+	if !ok {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return ErrTokenExceedsDeadline
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (t *Token) reserveN(ctx context.Context, now time.Time, n int, maxWait time.Duration) (time.Duration, bool) {
 	select {
 	case <-ctx.Done():
 		log.Errorf("fail to use rate limiter: %s", ctx.Err())
-		return false
+		return 0, false
 	default:
 	}
 
+	if maxWait < 0 {
+		maxWait = 0
+	}
+
 	if atomic.LoadUint32(&t.storeAlive) == 0 {
-		return t.degrade.AllowN(now, n)
+		return t.degradeReserveN(now, n, maxWait)
 	}
 
 	eval, err := t.store.Eval(ctx, tokenScript,
@@ -108,37 +185,48 @@ func (t *Token) reserveN(ctx context.Context, now time.Time, n int) bool {
 		[]string{
 			strconv.Itoa(t.rate),
 			strconv.Itoa(t.capacity),
-			strconv.FormatInt(now.Unix(), 10),
+			strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10),
 			strconv.Itoa(n),
+			strconv.FormatInt(int64(maxWait/time.Millisecond), 10),
 		},
 	)
 
-	// redis allowed == false
-	// Lua boolean false -> r Nil bulk reply
-	if err == redis.Nil {
-		return false
-	}
 	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
 		log.Errorf("fail to use rate limiter: %s", err)
-		return false
+		return 0, false
 	}
 
 	if err != nil {
 		log.Errorf("token limit eval err:%+v,resp:%v, use in-process limit instead", err, eval)
 		t.startMonitor()
-		return t.degrade.AllowN(now, n)
+		return t.degradeReserveN(now, n, maxWait)
 	}
 
-	code, ok := eval.(int64)
+	wait, ok := eval.(int64)
 	if !ok {
 		log.Errorf("token limit eval err:%+v,resp:%v, use in-process limit instead", err, eval)
 		t.startMonitor()
-		return t.degrade.AllowN(now, n)
+		return t.degradeReserveN(now, n, maxWait)
+	}
+
+	if wait < 0 {
+		return 0, false
 	}
+	return time.Duration(wait) * time.Millisecond, true
+}
 
-	// redis allowed == true
-	// Lua boolean true -> r integer reply with value of 1
-	return code == 1
+func (t *Token) degradeReserveN(now time.Time, n int, maxWait time.Duration) (time.Duration, bool) {
+	r := t.degrade.ReserveN(now, n)
+	if !r.OK() {
+		return 0, false
+	}
+
+	delay := r.DelayFrom(now)
+	if delay > maxWait {
+		r.CancelAt(now)
+		return 0, false
+	}
+	return delay, true
 }
 
 func (t *Token) startMonitor() {
@@ -155,8 +243,15 @@ func (t *Token) startMonitor() {
 	go t.heartbeat()
 }
 
+// monitoring reports whether redis is being probed
+func (t *Token) monitoring() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.monitorOn
+}
+
 func (t *Token) heartbeat() {
-	ticker := time.NewTicker(tokenPingInterval)
+	ticker := time.NewTicker(t.pingInterval)
 	defer func() {
 		ticker.Stop()
 		t.lock.Lock()
@@ -2,6 +2,8 @@ package ratelimit
 
 import (
 	"context"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -31,7 +33,7 @@ func TestToken_WithCtx(t *testing.T) {
 	for i := 0; i < total; i++ {
 		ok := l.Allow(ctx)
 		assert.False(t, ok)
-		assert.False(t, l.monitorOn)
+		assert.False(t, l.monitoring())
 	}
 }
 
@@ -112,3 +114,94 @@ func TestToken_TakeBurst(t *testing.T) {
 
 	assert.True(t, allowed >= capacity)
 }
+
+func TestToken_VersionedKeys(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer mini.Close()
+
+	// an empty bucket of the old layout, which kept timestamps in seconds
+	assert.Nil(t, mini.Set("{token-version-test}.token", "0"))
+	assert.Nil(t, mini.Set("{token-version-test}.timestamp", strconv.FormatInt(time.Now().Unix(), 10)))
+
+	token := NewToken(10, 5, redis.New(mini.Addr()), "token-version-test")
+	assert.Equal(t, "{token-version-test}.v2.token", token.tokenKey)
+	assert.Equal(t, "{token-version-test}.v2.timestamp", token.timestampKey)
+	assert.True(t, token.Allow(context.Background()))
+
+	assert.True(t, mini.Exists("{token-version-test}.v2.token"))
+	token0, _ := mini.Get("{token-version-test}.token")
+	assert.Equal(t, "0", token0)
+}
+
+func TestToken_ReserveN(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer mini.Close()
+
+	var (
+		ctx   = context.Background()
+		now   = time.Now()
+		token = NewToken(10, 5, redis.New(mini.Addr()), "token-reserve-test")
+	)
+
+	delay, ok := token.ReserveN(ctx, now, 5, 0)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), delay)
+
+	_, ok = token.ReserveN(ctx, now, 1, time.Millisecond*50)
+	assert.False(t, ok)
+
+	// 10 tokens per second, reserve the next one
+	delay, ok = token.ReserveN(ctx, now, 1, time.Millisecond*100)
+	assert.True(t, ok)
+	assert.Equal(t, time.Millisecond*100, delay)
+
+	delay, ok = token.ReserveN(ctx, now, 1, time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, time.Millisecond*200, delay)
+}
+
+func TestToken_Wait(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer mini.Close()
+
+	token := NewToken(10, 1, redis.New(mini.Addr()), "token-wait-test")
+	assert.Nil(t, token.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	assert.ErrorIs(t, token.Wait(ctx), ErrTokenExceedsDeadline)
+
+	start := time.Now()
+	assert.Nil(t, token.Wait(context.Background()))
+	assert.True(t, time.Since(start) >= time.Millisecond*50)
+}
+
+func TestToken_WaitDegrade(t *testing.T) {
+	mini, err := miniredis.Run()
+	assert.Nil(t, err)
+
+	token := NewToken(10, 1, redis.New(mini.Addr()), "token-wait-degrade-test", WithTokenProbeInterval(time.Millisecond*10))
+	mini.Close()
+
+	assert.Nil(t, token.Wait(context.Background()))
+	assert.True(t, token.monitoring())
+	time.Sleep(time.Millisecond * 150)
+	assert.True(t, token.Allow(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	assert.ErrorIs(t, token.Wait(ctx), ErrTokenExceedsDeadline)
+
+	start := time.Now()
+	assert.Nil(t, token.Wait(context.Background()))
+	assert.True(t, time.Since(start) >= time.Millisecond*50)
+
+	// switch back after redis recovered
+	assert.Nil(t, mini.Restart())
+	assert.Eventually(t, func() bool {
+		return atomic.LoadUint32(&token.storeAlive) == 1
+	}, time.Second*5, time.Millisecond*10)
+}
@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/log"
+	"github.com/sado0823/go-kitx/kit/registry"
+)
+
+const (
+	defaultRetryInterval = time.Second
+	defaultFirstWait     = time.Second * 3
+)
+
+var _ registry.Discovery = (*Discovery)(nil)
+
+type (
+	OptionFn func(*Discovery)
+
+	// Discovery persists the last instance list of each service to dir,
+	// the list is served when the wrapped registry.Discovery is down, e.g. etcd is unreachable at startup.
+	// an empty list never replaces a non-empty one, since losing all instances is more likely a registry failure
+	Discovery struct {
+		registry.Discovery
+		dir           string
+		retryInterval time.Duration
+		firstWait     time.Duration
+
+		lock    sync.Mutex
+		entries map[string]*entry
+	}
+
+	// Status of the instance list of a service
+	Status struct {
+		// Stale is true if the list is served from cache because registry fails
+		Stale bool
+		// UpdatedAt is the last time the list is got from registry
+		UpdatedAt time.Time
+		// Instances count of the list
+		Instances int
+	}
+
+	entry struct {
+		svcs      []*registry.Service
+		updatedAt time.Time
+		stale     bool
+	}
+
+	snapshot struct {
+		UpdatedAt time.Time           `json:"updated_at"`
+		Services  []*registry.Service `json:"services"`
+	}
+)
+
+// WithRetryInterval set how long to wait before retrying a failed watch
+func WithRetryInterval(interval time.Duration) OptionFn {
+	return func(d *Discovery) {
+		d.retryInterval = interval
+	}
+}
+
+// WithFirstWait set how long the first Next of watcher waits for registry before serving cache
+func WithFirstWait(wait time.Duration) OptionFn {
+	return func(d *Discovery) {
+		d.firstWait = wait
+	}
+}
+
+func NewDiscovery(discovery registry.Discovery, dir string, options ...OptionFn) *Discovery {
+	d := &Discovery{
+		Discovery:     discovery,
+		dir:           dir,
+		retryInterval: defaultRetryInterval,
+		firstWait:     defaultFirstWait,
+		entries:       make(map[string]*entry),
+	}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// Get instances from registry, or from cache if registry fails
+func (d *Discovery) Get(ctx context.Context, name string) ([]*registry.Service, error) {
+	svcs, err := d.Discovery.Get(ctx, name)
+	if err != nil {
+		if cached, ok := d.fail(name); ok {
+			log.Warnf("registry cache get %s err:%+v, serve cache", name, err)
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	return d.apply(name, svcs), nil
+}
+
+// Watch instances from registry, cache is served when registry fails,
+// the watcher keeps retrying and switches back after registry recovers
+func (d *Discovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	w := &watcher{
+		d:       d,
+		name:    name,
+		updated: make(chan struct{}, 1),
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	go w.pump()
+	return w, nil
+}
+
+// Status returns status of the instance list of name
+func (d *Discovery) Status(name string) Status {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	e := d.entry(name)
+	return Status{Stale: e.stale, UpdatedAt: e.updatedAt, Instances: len(e.svcs)}
+}
+
+// apply svcs from registry and returns the list to serve
+func (d *Discovery) apply(name string, svcs []*registry.Service) []*registry.Service {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	e := d.entry(name)
+	if len(svcs) == 0 && len(e.svcs) > 0 {
+		log.Warnf("registry cache refuse empty instances of %s, keep %d", name, len(e.svcs))
+		return e.svcs
+	}
+
+	e.svcs, e.updatedAt, e.stale = svcs, time.Now(), false
+	if err := d.save(name, e); err != nil {
+		log.Errorf("registry cache save %s err:%+v", name, err)
+	}
+	return svcs
+}
+
+// fail marks the list of name stale, returns the cached list if any
+func (d *Discovery) fail(name string) ([]*registry.Service, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	e := d.entry(name)
+	if len(e.svcs) == 0 {
+		return nil, false
+	}
+	e.stale = true
+	return e.svcs, true
+}
+
+// entry of name, it's loaded from disk at the first time, must be called with lock
+func (d *Discovery) entry(name string) *entry {
+	if e, ok := d.entries[name]; ok {
+		return e
+	}
+
+	e := &entry{stale: true}
+	if content, err := ioutil.ReadFile(d.path(name)); err == nil {
+		var s snapshot
+		if err = json.Unmarshal(content, &s); err != nil {
+			log.Errorf("registry cache load %s err:%+v", name, err)
+		} else {
+			e.svcs, e.updatedAt = s.Services, s.UpdatedAt
+		}
+	}
+	d.entries[name] = e
+	return e
+}
+
+// save to a temp file and rename it, a crash never leaves a broken one
+func (d *Discovery) save(name string, e *entry) error {
+	content, err := json.Marshal(snapshot{UpdatedAt: e.updatedAt, Services: e.svcs})
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(d.dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(d.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), d.path(name))
+}
+
+func (d *Discovery) path(name string) string {
+	return filepath.Join(d.dir, url.PathEscape(name)+".json")
+}
@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/registry"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errDown = errors.New("registry down")
+
+// flaky wraps registry.Memory, it fails when down
+type flaky struct {
+	*registry.Memory
+	lock sync.Mutex
+	down bool
+	// broken watchers are returned while it's set
+	broken  bool
+	watches int
+	stops   int
+}
+
+// brokenWatcher fails on every Next
+type brokenWatcher struct {
+	f *flaky
+}
+
+func (b *brokenWatcher) Next() ([]*registry.Service, error) {
+	return nil, errDown
+}
+
+func (b *brokenWatcher) Stop() error {
+	b.f.lock.Lock()
+	b.f.stops++
+	b.f.lock.Unlock()
+	return nil
+}
+
+func (f *flaky) setDown(down bool) {
+	f.lock.Lock()
+	f.down = down
+	f.lock.Unlock()
+}
+
+func (f *flaky) isDown() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.down
+}
+
+func (f *flaky) Get(ctx context.Context, name string) ([]*registry.Service, error) {
+	if f.isDown() {
+		return nil, errDown
+	}
+	return f.Memory.Get(ctx, name)
+}
+
+func (f *flaky) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	if f.isDown() {
+		return nil, errDown
+	}
+	f.lock.Lock()
+	f.watches++
+	broken := f.broken
+	f.lock.Unlock()
+	if broken {
+		return &brokenWatcher{f: f}, nil
+	}
+	return f.Memory.Watch(ctx, name)
+}
+
+func TestDiscovery_Get(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		dir     = t.TempDir()
+		backend = &flaky{Memory: registry.NewMemory()}
+		svc     = &registry.Service{ID: "1", Name: "demo", Endpoints: []string{"grpc://127.0.0.1:9000"}}
+	)
+	assert.Nil(t, backend.Register(ctx, svc))
+
+	d := NewDiscovery(backend, dir)
+	assert.True(t, d.Status("demo").Stale)
+	svcs, err := d.Get(ctx, "demo")
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{svc}, svcs)
+	assert.False(t, d.Status("demo").Stale)
+
+	// refuse empty
+	assert.Nil(t, backend.Deregister(ctx, svc))
+	svcs, err = d.Get(ctx, "demo")
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{svc}, svcs)
+
+	// served from disk by a new one
+	backend.setDown(true)
+	d = NewDiscovery(backend, dir)
+	svcs, err = d.Get(ctx, "demo")
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{svc}, svcs)
+	status := d.Status("demo")
+	assert.True(t, status.Stale)
+	assert.Equal(t, 1, status.Instances)
+	assert.False(t, status.UpdatedAt.IsZero())
+
+	_, err = d.Get(ctx, "unknown")
+	assert.ErrorIs(t, err, errDown)
+}
+
+func TestDiscovery_Watch(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		dir     = t.TempDir()
+		backend = &flaky{Memory: registry.NewMemory()}
+		svc1    = &registry.Service{ID: "1", Name: "demo", Endpoints: []string{"grpc://127.0.0.1:9001"}}
+		svc2    = &registry.Service{ID: "2", Name: "demo", Endpoints: []string{"grpc://127.0.0.1:9002"}}
+	)
+	assert.Nil(t, backend.Register(ctx, svc1))
+	_, err := NewDiscovery(backend, dir).Get(ctx, "demo")
+	assert.Nil(t, err)
+
+	// registry is down at startup
+	backend.setDown(true)
+	d := NewDiscovery(backend, dir, WithRetryInterval(time.Millisecond*10))
+	watcher, err := d.Watch(ctx, "demo")
+	assert.Nil(t, err)
+	defer watcher.Stop()
+
+	svcs, err := watcher.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{svc1}, svcs)
+	assert.True(t, d.Status("demo").Stale)
+
+	// switch back after recovered
+	assert.Nil(t, backend.Register(ctx, svc2))
+	backend.setDown(false)
+	svcs, err = watcher.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{svc1, svc2}, svcs)
+	assert.False(t, d.Status("demo").Stale)
+
+	// refuse empty
+	assert.Nil(t, backend.Deregister(ctx, svc1))
+	svcs, err = watcher.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{svc2}, svcs)
+	assert.Nil(t, backend.Deregister(ctx, svc2))
+	svcs, err = watcher.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{svc2}, svcs)
+
+	assert.Nil(t, watcher.Stop())
+	_, err = watcher.Next()
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDiscovery_WatchFirstWait(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		dir     = t.TempDir()
+		backend = &flaky{Memory: registry.NewMemory()}
+		svc     = &registry.Service{ID: "1", Name: "demo"}
+	)
+	assert.Nil(t, backend.Register(ctx, svc))
+	_, err := NewDiscovery(backend, dir).Get(ctx, "demo")
+	assert.Nil(t, err)
+	assert.Nil(t, backend.Deregister(ctx, svc))
+
+	// registry is up but empty, cache is served after first wait
+	d := NewDiscovery(backend, dir, WithFirstWait(time.Millisecond*50))
+	watcher, err := d.Watch(ctx, "demo")
+	assert.Nil(t, err)
+	defer watcher.Stop()
+
+	svcs, err := watcher.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{svc}, svcs)
+}
+
+func TestDiscovery_WatchRenew(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		dir     = t.TempDir()
+		backend = &flaky{Memory: registry.NewMemory(), broken: true}
+		svc     = &registry.Service{ID: "1", Name: "demo", Endpoints: []string{"grpc://127.0.0.1:9001"}}
+	)
+	assert.Nil(t, backend.Register(ctx, svc))
+
+	d := NewDiscovery(backend, dir, WithRetryInterval(time.Millisecond*10))
+	watcher, err := d.Watch(ctx, "demo")
+	assert.Nil(t, err)
+	defer watcher.Stop()
+
+	// the broken watcher is stopped and watched again
+	assert.Eventually(t, func() bool {
+		backend.lock.Lock()
+		defer backend.lock.Unlock()
+		return backend.watches >= 2 && backend.stops >= 1
+	}, time.Second, time.Millisecond*5)
+	backend.lock.Lock()
+	backend.broken = false
+	backend.lock.Unlock()
+
+	next := make(chan []*registry.Service, 1)
+	go func() {
+		svcs, _ := watcher.Next()
+		next <- svcs
+	}()
+	select {
+	case svcs := <-next:
+		assert.Equal(t, []*registry.Service{svc}, svcs)
+	case <-time.After(time.Second):
+		t.Fatal("no update after watched again")
+	}
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/log"
+	"github.com/sado0823/go-kitx/kit/registry"
+)
+
+type watcher struct {
+	d      *Discovery
+	name   string
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock   sync.Mutex
+	svcs   []*registry.Service
+	inner  registry.Watcher
+	served bool
+	// buffered by 1, updates are merged if not consumed in time
+	updated chan struct{}
+}
+
+// Next returns instances when they change, the first call waits for registry for a while,
+// then serves cache if registry fails
+func (w *watcher) Next() ([]*registry.Service, error) {
+	w.lock.Lock()
+	first := !w.served
+	w.served = true
+	w.lock.Unlock()
+
+	if first {
+		timer := time.NewTimer(w.d.firstWait)
+		defer timer.Stop()
+
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-w.updated:
+			return w.current(), nil
+		case <-timer.C:
+			if cached, ok := w.d.fail(w.name); ok {
+				log.Warnf("registry cache watch %s timeout, serve cache", w.name)
+				return cached, nil
+			}
+		}
+	}
+
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case <-w.updated:
+		return w.current(), nil
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.inner != nil {
+		return w.inner.Stop()
+	}
+	return nil
+}
+
+func (w *watcher) current() []*registry.Service {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.svcs
+}
+
+func (w *watcher) set(svcs []*registry.Service) {
+	w.lock.Lock()
+	w.svcs = svcs
+	w.lock.Unlock()
+
+	select {
+	case w.updated <- struct{}{}:
+	default:
+	}
+}
+
+// pump watches registry and retries on failure, cache is served while failing,
+// a watcher is dropped once it fails and a new one is created
+func (w *watcher) pump() {
+	for {
+		if w.ctx.Err() != nil {
+			return
+		}
+
+		inner, err := w.d.Discovery.Watch(w.ctx, w.name)
+		if err != nil {
+			w.failed(err)
+			continue
+		}
+		w.lock.Lock()
+		w.inner = inner
+		w.lock.Unlock()
+
+		w.watch(inner)
+	}
+}
+
+// watch serves updates of inner until it fails
+func (w *watcher) watch(inner registry.Watcher) {
+	for {
+		svcs, err := inner.Next()
+		if err != nil {
+			w.lock.Lock()
+			w.inner = nil
+			w.lock.Unlock()
+			_ = inner.Stop()
+			if w.ctx.Err() == nil {
+				w.failed(err)
+			}
+			return
+		}
+		w.set(w.d.apply(w.name, svcs))
+	}
+}
+
+func (w *watcher) failed(err error) {
+	log.Errorf("registry cache watch %s err:%+v", w.name, err)
+	if cached, ok := w.d.fail(w.name); ok {
+		w.lock.Lock()
+		changed := len(w.svcs) == 0
+		w.lock.Unlock()
+		// serve cache at once if nothing is served
+		if changed {
+			w.set(cached)
+		}
+	}
+
+	select {
+	case <-w.ctx.Done():
+	case <-time.After(w.d.retryInterval):
+	}
+}
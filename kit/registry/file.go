@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/log"
+
+	"gopkg.in/yaml.v3"
+)
+
+const fileInterval = time.Second * 2
+
+var _ Discovery = (*File)(nil)
+
+type (
+	FileOptionFn func(*File)
+
+	// File is a static Discovery from a yaml file, which is a list of services:
+	//
+	//	- id: demo-1
+	//	  name: demo
+	//	  version: v1
+	//	  metadata:
+	//	    zone: a
+	//	  endpoints:
+	//	    - grpc://127.0.0.1:9000
+	//
+	// the file is reloaded on change, watchers are notified then.
+	// a broken file is ignored and the last good one is kept
+	File struct {
+		path     string
+		interval time.Duration
+		memory   *Memory
+		content  []byte
+		doneCh   chan struct{}
+		once     sync.Once
+	}
+)
+
+// WithFileInterval set how often the file is checked for changes
+func WithFileInterval(interval time.Duration) FileOptionFn {
+	return func(f *File) {
+		f.interval = interval
+	}
+}
+
+// NewFile create a File discovery, the file must be valid at the first time
+func NewFile(path string, options ...FileOptionFn) (*File, error) {
+	f := &File{
+		path:     path,
+		interval: fileInterval,
+		memory:   NewMemory(),
+		doneCh:   make(chan struct{}),
+	}
+	for _, option := range options {
+		option(f)
+	}
+
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+
+	go f.reload()
+	return f, nil
+}
+
+func (f *File) Get(ctx context.Context, name string) ([]*Service, error) {
+	return f.memory.Get(ctx, name)
+}
+
+func (f *File) Watch(ctx context.Context, name string) (Watcher, error) {
+	return f.memory.Watch(ctx, name)
+}
+
+// Close stop reloading the file
+func (f *File) Close() {
+	f.once.Do(func() {
+		close(f.doneCh)
+	})
+}
+
+func (f *File) reload() {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.doneCh:
+			return
+		case <-ticker.C:
+			if err := f.load(); err != nil {
+				log.Errorf("registry file %s reload err:%+v", f.path, err)
+			}
+		}
+	}
+}
+
+func (f *File) load() error {
+	content, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	if f.content != nil && bytes.Equal(content, f.content) {
+		return nil
+	}
+
+	var svcs []*Service
+	if err = yaml.Unmarshal(content, &svcs); err != nil {
+		return err
+	}
+
+	f.memory.replace(svcs)
+	f.content = content
+	return nil
+}
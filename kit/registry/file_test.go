@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testFileContent = `
+- id: demo-1
+  name: demo
+  version: v1
+  metadata:
+    zone: a
+  endpoints:
+    - grpc://127.0.0.1:9001
+- id: demo-2
+  name: demo
+  version: v2
+  endpoints:
+    - grpc://127.0.0.1:9002
+- id: other-1
+  name: other
+  endpoints:
+    - http://127.0.0.1:8001
+`
+
+func TestFile(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		path = filepath.Join(t.TempDir(), "services.yaml")
+	)
+
+	_, err := NewFile(path)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte(testFileContent), 0o644))
+	file, err := NewFile(path, WithFileInterval(time.Millisecond*10))
+	assert.Nil(t, err)
+	defer file.Close()
+
+	svcs, err := file.Get(ctx, "demo")
+	assert.Nil(t, err)
+	assert.Equal(t, []*Service{
+		{ID: "demo-1", Name: "demo", Version: "v1", Metadata: map[string]string{"zone": "a"}, Endpoints: []string{"grpc://127.0.0.1:9001"}},
+		{ID: "demo-2", Name: "demo", Version: "v2", Endpoints: []string{"grpc://127.0.0.1:9002"}},
+	}, svcs)
+
+	watcher, err := file.Watch(ctx, "demo")
+	assert.Nil(t, err)
+	defer watcher.Stop()
+	svcs, err = watcher.Next()
+	assert.Nil(t, err)
+	assert.Len(t, svcs, 2)
+
+	// broken file is ignored
+	assert.Nil(t, ioutil.WriteFile(path, []byte("- id: [broken"), 0o644))
+	time.Sleep(time.Millisecond * 50)
+	svcs, _ = file.Get(ctx, "demo")
+	assert.Len(t, svcs, 2)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`
+- id: demo-3
+  name: demo
+  endpoints:
+    - grpc://127.0.0.1:9003
+`), 0o644))
+	svcs, err = watcher.Next()
+	assert.Nil(t, err)
+	assert.Len(t, svcs, 1)
+	assert.Equal(t, "demo-3", svcs[0].ID)
+}
@@ -0,0 +1,281 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/log"
+	"github.com/sado0823/go-kitx/kit/registry"
+)
+
+const (
+	defaultInterval  = time.Second * 5
+	defaultTimeout   = time.Second
+	defaultThreshold = 2
+)
+
+var _ registry.Discovery = (*Discovery)(nil)
+
+type (
+	OptionFn func(*Discovery)
+
+	// Discovery probes instances of the wrapped registry.Discovery actively,
+	// unhealthy instances are hidden until they recover, even if their registrations are alive.
+	// all instances are returned if none is healthy, so probing never takes the service down
+	Discovery struct {
+		registry.Discovery
+		prober    Prober
+		interval  time.Duration
+		timeout   time.Duration
+		threshold int
+	}
+
+	watcher struct {
+		d      *Discovery
+		inner  registry.Watcher
+		ctx    context.Context
+		cancel context.CancelFunc
+
+		lock    sync.Mutex
+		svcs    []*registry.Service
+		states  map[string]*state
+		pending chan result
+		// health changed, buffered by 1
+		changed chan struct{}
+		// instances changed, probe at once, buffered by 1
+		kick chan struct{}
+	}
+
+	state struct {
+		healthy  bool
+		failures int
+	}
+
+	result struct {
+		svcs []*registry.Service
+		err  error
+	}
+)
+
+// WithInterval set how often instances are probed
+func WithInterval(interval time.Duration) OptionFn {
+	return func(d *Discovery) {
+		d.interval = interval
+	}
+}
+
+// WithTimeout set timeout of each probe
+func WithTimeout(timeout time.Duration) OptionFn {
+	return func(d *Discovery) {
+		d.timeout = timeout
+	}
+}
+
+// WithThreshold set how many consecutive failures make an instance unhealthy,
+// one success makes it healthy again
+func WithThreshold(threshold int) OptionFn {
+	return func(d *Discovery) {
+		d.threshold = threshold
+	}
+}
+
+func NewDiscovery(discovery registry.Discovery, prober Prober, options ...OptionFn) *Discovery {
+	d := &Discovery{
+		Discovery: discovery,
+		prober:    prober,
+		interval:  defaultInterval,
+		timeout:   defaultTimeout,
+		threshold: defaultThreshold,
+	}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// Get returns healthy instances, they are probed once
+func (d *Discovery) Get(ctx context.Context, name string) ([]*registry.Service, error) {
+	svcs, err := d.Discovery.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := d.probe(ctx, svcs)
+	healthy := make([]*registry.Service, 0, len(svcs))
+	for i, svc := range svcs {
+		if errs[i] == nil {
+			healthy = append(healthy, svc)
+		}
+	}
+	if len(healthy) == 0 {
+		return svcs, nil
+	}
+	return healthy, nil
+}
+
+func (d *Discovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	inner, err := d.Discovery.Watch(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &watcher{
+		d:       d,
+		inner:   inner,
+		states:  make(map[string]*state),
+		changed: make(chan struct{}, 1),
+		kick:    make(chan struct{}, 1),
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	go w.probeLoop()
+	return w, nil
+}
+
+// probe instances concurrently, instances without endpoints to probe are healthy
+func (d *Discovery) probe(ctx context.Context, svcs []*registry.Service) []error {
+	var (
+		errs = make([]error, len(svcs))
+		wg   sync.WaitGroup
+	)
+	for i, svc := range svcs {
+		wg.Add(1)
+		go func(i int, svc *registry.Service) {
+			defer wg.Done()
+
+			probeCtx, cancel := context.WithTimeout(ctx, d.timeout)
+			defer cancel()
+			if err := d.prober(probeCtx, svc); err != nil && !errors.Is(err, ErrNoEndpoint) {
+				errs[i] = err
+			}
+		}(i, svc)
+	}
+	wg.Wait()
+	return errs
+}
+
+// Next returns healthy instances when instances or their health change
+func (w *watcher) Next() ([]*registry.Service, error) {
+	if w.pending == nil {
+		w.pending = make(chan result, 1)
+		go func(ch chan result) {
+			svcs, err := w.inner.Next()
+			ch <- result{svcs: svcs, err: err}
+		}(w.pending)
+	}
+
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case r := <-w.pending:
+		w.pending = nil
+		if r.err != nil {
+			return nil, r.err
+		}
+		w.update(r.svcs)
+		return w.healthy(), nil
+	case <-w.changed:
+		return w.healthy(), nil
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	return w.inner.Stop()
+}
+
+func (w *watcher) update(svcs []*registry.Service) {
+	w.lock.Lock()
+	w.svcs = svcs
+	// new instances are healthy until probed
+	states := make(map[string]*state, len(svcs))
+	for _, svc := range svcs {
+		if s, ok := w.states[svc.ID]; ok {
+			states[svc.ID] = s
+		} else {
+			states[svc.ID] = &state{healthy: true}
+		}
+	}
+	w.states = states
+	w.lock.Unlock()
+
+	select {
+	case w.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) healthy() []*registry.Service {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	healthy := make([]*registry.Service, 0, len(w.svcs))
+	for _, svc := range w.svcs {
+		if w.states[svc.ID].healthy {
+			healthy = append(healthy, svc)
+		}
+	}
+	if len(healthy) == 0 {
+		return w.svcs
+	}
+	return healthy
+}
+
+func (w *watcher) probeLoop() {
+	ticker := time.NewTicker(w.d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.kick:
+		}
+
+		w.lock.Lock()
+		svcs := w.svcs
+		w.lock.Unlock()
+		if len(svcs) == 0 {
+			continue
+		}
+
+		errs := w.d.probe(w.ctx, svcs)
+		if w.ctx.Err() != nil {
+			return
+		}
+
+		var changed bool
+		w.lock.Lock()
+		for i, svc := range svcs {
+			s, ok := w.states[svc.ID]
+			if !ok {
+				// removed while probing
+				continue
+			}
+
+			if errs[i] == nil {
+				s.failures = 0
+				changed = changed || !s.healthy
+				s.healthy = true
+				continue
+			}
+
+			s.failures++
+			if s.healthy && s.failures >= w.d.threshold {
+				log.Warnf("health check instance %s of %s unhealthy, err:%+v", svc.ID, svc.Name, errs[i])
+				s.healthy = false
+				changed = true
+			}
+		}
+		w.lock.Unlock()
+
+		if changed {
+			select {
+			case w.changed <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package health
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/registry"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func newHealthServer(t *testing.T) (string, *health.Server, func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(srv, hs)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	return lis.Addr().String(), hs, srv.Stop
+}
+
+func TestGRPC(t *testing.T) {
+	addr, hs, stop := newHealthServer(t)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	prober := GRPC()
+	assert.Nil(t, prober(ctx, &registry.Service{Endpoints: []string{"http://" + addr, "grpc://" + addr}}))
+
+	hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	assert.NotNil(t, prober(ctx, &registry.Service{Endpoints: []string{"grpc://" + addr}}))
+	assert.ErrorIs(t, prober(ctx, &registry.Service{Endpoints: []string{"http://" + addr}}), ErrNoEndpoint)
+}
+
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	svc := &registry.Service{Endpoints: []string{srv.URL}}
+	assert.Nil(t, HTTP("/healthz")(ctx, svc))
+	assert.NotNil(t, HTTP("/other")(ctx, svc))
+}
+
+func TestDiscovery(t *testing.T) {
+	addr, hs, stop := newHealthServer(t)
+	defer stop()
+
+	var (
+		ctx     = context.Background()
+		memory  = registry.NewMemory()
+		healthy = &registry.Service{ID: "1", Name: "demo", Endpoints: []string{"grpc://" + addr}}
+		down    = &registry.Service{ID: "2", Name: "demo", Endpoints: []string{"grpc://127.0.0.1:1"}}
+		noProbe = &registry.Service{ID: "3", Name: "demo"}
+		d       = NewDiscovery(memory, GRPC(), WithInterval(time.Millisecond*20), WithTimeout(time.Millisecond*200), WithThreshold(1))
+	)
+	assert.Nil(t, memory.Register(ctx, healthy))
+	assert.Nil(t, memory.Register(ctx, down))
+	assert.Nil(t, memory.Register(ctx, noProbe))
+
+	svcs, err := d.Get(ctx, "demo")
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{healthy, noProbe}, svcs)
+
+	watcher, err := d.Watch(ctx, "demo")
+	assert.Nil(t, err)
+	defer watcher.Stop()
+
+	// all are healthy before probed
+	svcs, err = watcher.Next()
+	assert.Nil(t, err)
+	assert.Len(t, svcs, 3)
+
+	svcs, err = watcher.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{healthy, noProbe}, svcs)
+
+	hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	svcs, err = watcher.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{noProbe}, svcs)
+
+	// recovered
+	hs.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	svcs, err = watcher.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{healthy, noProbe}, svcs)
+
+	// instances changed
+	assert.Nil(t, memory.Deregister(ctx, noProbe))
+	svcs, err = watcher.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{healthy}, svcs)
+}
+
+func TestDiscovery_AllUnhealthy(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		memory = registry.NewMemory()
+		down   = &registry.Service{ID: "1", Name: "demo", Endpoints: []string{"grpc://127.0.0.1:1"}}
+		d      = NewDiscovery(memory, GRPC(), WithTimeout(time.Millisecond*100))
+	)
+	assert.Nil(t, memory.Register(ctx, down))
+
+	svcs, err := d.Get(ctx, "demo")
+	assert.Nil(t, err)
+	assert.Equal(t, []*registry.Service{down}, svcs)
+}
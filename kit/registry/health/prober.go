@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/sado0823/go-kitx/kit/registry"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var ErrNoEndpoint = errors.New("no endpoint to probe")
+
+// Prober checks whether an instance serves traffic, ctx carries the probe timeout
+type Prober func(ctx context.Context, svc *registry.Service) error
+
+// GRPC probes the grpc:// or grpcs:// endpoint by grpc_health_v1, which is registered by transport/grpc.Server
+func GRPC() Prober {
+	return func(ctx context.Context, svc *registry.Service) error {
+		u, err := endpointOf(svc, "grpc", "grpcs")
+		if err != nil {
+			return err
+		}
+
+		creds := insecure.NewCredentials()
+		if u.Scheme == "grpcs" {
+			creds = credentials.NewTLS(&tls.Config{})
+		}
+		conn, err := grpc.DialContext(ctx, u.Host, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			return err
+		}
+		if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			return fmt.Errorf("grpc health status: %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+// HTTP probes path of the http:// or https:// endpoint, 2xx is healthy
+func HTTP(path string) Prober {
+	return func(ctx context.Context, svc *registry.Service) error {
+		u, err := endpointOf(svc, "http", "https")
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, path), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("http health status: %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+func endpointOf(svc *registry.Service, schemes ...string) (*url.URL, error) {
+	for _, e := range svc.Endpoints {
+		u, err := url.Parse(e)
+		if err != nil {
+			return nil, err
+		}
+		for _, scheme := range schemes {
+			if u.Scheme == scheme {
+				return u, nil
+			}
+		}
+	}
+	return nil, ErrNoEndpoint
+}
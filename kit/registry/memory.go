@@ -0,0 +1,168 @@
+package registry
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var (
+	_ Registrar = (*Memory)(nil)
+	_ Discovery = (*Memory)(nil)
+)
+
+type (
+	// Memory is an in-process registry, it's for tests and single process use
+	Memory struct {
+		lock     sync.RWMutex
+		services map[string]map[string]*Service
+		watchers map[string]map[*memoryWatcher]struct{}
+	}
+
+	memoryWatcher struct {
+		memory *Memory
+		name   string
+		ctx    context.Context
+		cancel context.CancelFunc
+		// buffered by 1, changes are merged if not consumed in time
+		changed chan struct{}
+		first   bool
+	}
+)
+
+func NewMemory() *Memory {
+	return &Memory{
+		services: make(map[string]map[string]*Service),
+		watchers: make(map[string]map[*memoryWatcher]struct{}),
+	}
+}
+
+// Register add or replace the instance of svc.ID
+func (m *Memory) Register(ctx context.Context, svc *Service) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.services[svc.Name] == nil {
+		m.services[svc.Name] = make(map[string]*Service)
+	}
+	m.services[svc.Name][svc.ID] = svc
+	m.notify(svc.Name)
+	return nil
+}
+
+func (m *Memory) Deregister(ctx context.Context, svc *Service) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.services[svc.Name][svc.ID]; !ok {
+		return nil
+	}
+	delete(m.services[svc.Name], svc.ID)
+	if len(m.services[svc.Name]) == 0 {
+		delete(m.services, svc.Name)
+	}
+	m.notify(svc.Name)
+	return nil
+}
+
+// Get returns instances of name sorted by id
+func (m *Memory) Get(ctx context.Context, name string) ([]*Service, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.list(name), nil
+}
+
+func (m *Memory) Watch(ctx context.Context, name string) (Watcher, error) {
+	w := &memoryWatcher{
+		memory:  m,
+		name:    name,
+		changed: make(chan struct{}, 1),
+		first:   true,
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+
+	m.lock.Lock()
+	if m.watchers[name] == nil {
+		m.watchers[name] = make(map[*memoryWatcher]struct{})
+	}
+	m.watchers[name][w] = struct{}{}
+	m.lock.Unlock()
+	return w, nil
+}
+
+// replace all instances, only watchers of changed names are notified
+func (m *Memory) replace(svcs []*Service) {
+	services := make(map[string]map[string]*Service)
+	for _, svc := range svcs {
+		if services[svc.Name] == nil {
+			services[svc.Name] = make(map[string]*Service)
+		}
+		services[svc.Name][svc.ID] = svc
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	old := m.services
+	m.services = services
+	for name := range m.watchers {
+		if !reflect.DeepEqual(old[name], services[name]) {
+			m.notify(name)
+		}
+	}
+}
+
+func (m *Memory) list(name string) []*Service {
+	svcs := make([]*Service, 0, len(m.services[name]))
+	for _, svc := range m.services[name] {
+		svcs = append(svcs, svc)
+	}
+	sort.Slice(svcs, func(i, j int) bool {
+		return svcs[i].ID < svcs[j].ID
+	})
+	return svcs
+}
+
+func (m *Memory) notify(name string) {
+	for w := range m.watchers[name] {
+		select {
+		case w.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Next returns instances at the first time if not empty, then blocks until instances change
+func (w *memoryWatcher) Next() ([]*Service, error) {
+	if w.first {
+		w.first = false
+		if svcs, _ := w.memory.Get(w.ctx, w.name); len(svcs) > 0 {
+			// changes before are included
+			select {
+			case <-w.changed:
+			default:
+			}
+			return svcs, nil
+		}
+	}
+
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case <-w.changed:
+		return w.memory.Get(w.ctx, w.name)
+	}
+}
+
+func (w *memoryWatcher) Stop() error {
+	w.cancel()
+
+	w.memory.lock.Lock()
+	delete(w.memory.watchers[w.name], w)
+	if len(w.memory.watchers[w.name]) == 0 {
+		delete(w.memory.watchers, w.name)
+	}
+	w.memory.lock.Unlock()
+	return nil
+}
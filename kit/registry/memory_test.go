@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		memory = NewMemory()
+		svc1   = &Service{ID: "1", Name: "demo", Endpoints: []string{"grpc://127.0.0.1:9001"}}
+		svc2   = &Service{ID: "2", Name: "demo", Endpoints: []string{"grpc://127.0.0.1:9002"}}
+	)
+
+	watcher, err := memory.Watch(ctx, "demo")
+	assert.Nil(t, err)
+
+	// blocks at the first time if empty
+	nextCh := make(chan []*Service, 1)
+	go func() {
+		svcs, err := watcher.Next()
+		assert.Nil(t, err)
+		nextCh <- svcs
+	}()
+	select {
+	case <-nextCh:
+		t.Fatal("should block")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	assert.Nil(t, memory.Register(ctx, svc1))
+	assert.Equal(t, []*Service{svc1}, <-nextCh)
+
+	assert.Nil(t, memory.Register(ctx, svc2))
+	assert.Nil(t, memory.Register(ctx, &Service{ID: "3", Name: "other"}))
+	svcs, err := watcher.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Service{svc1, svc2}, svcs)
+
+	assert.Nil(t, memory.Deregister(ctx, svc1))
+	svcs, err = watcher.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Service{svc2}, svcs)
+
+	svcs, err = memory.Get(ctx, "demo")
+	assert.Nil(t, err)
+	assert.Equal(t, []*Service{svc2}, svcs)
+
+	// returns at once for a new watcher
+	other, err := memory.Watch(ctx, "demo")
+	assert.Nil(t, err)
+	svcs, err = other.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Service{svc2}, svcs)
+
+	assert.Nil(t, watcher.Stop())
+	_, err = watcher.Next()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, other.Stop())
+	assert.Len(t, memory.watchers, 0)
+}
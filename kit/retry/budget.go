@@ -0,0 +1,46 @@
+package retry
+
+import (
+	"math"
+	"sync"
+)
+
+// Budget is a token bucket of retries, each request deposits ratio tokens and each retry withdraws one,
+// so retries are at most ratio of requests over time, which prevents retry storms when backend is down.
+// the bucket starts full, capacity retries are allowed before any request is made
+type Budget struct {
+	lock     sync.Mutex
+	ratio    float64
+	capacity float64
+	tokens   float64
+}
+
+// NewBudget create a Budget, e.g. NewBudget(0.1, 10) allows 10% extra load by retries
+func NewBudget(ratio float64, capacity int) *Budget {
+	if ratio <= 0 || capacity <= 0 {
+		panic("invalid retry budget param")
+	}
+
+	return &Budget{
+		ratio:    ratio,
+		capacity: float64(capacity),
+		tokens:   float64(capacity),
+	}
+}
+
+func (b *Budget) deposit() {
+	b.lock.Lock()
+	b.tokens = math.Min(b.capacity, b.tokens+b.ratio)
+	b.lock.Unlock()
+}
+
+func (b *Budget) withdraw() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,85 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/sado0823/go-kitx/errorx"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Classifier reports whether an error is retryable
+type Classifier func(err error) bool
+
+// DefaultClassifier retries transient errors only:
+// 429, 502, 503, 504 or gRPC ResourceExhausted, Unavailable, DeadlineExceeded,
+// canceled requests are never retried
+var DefaultClassifier = Any(
+	ByCodes(http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout),
+	ByGRPCCodes(codes.ResourceExhausted, codes.Unavailable, codes.DeadlineExceeded),
+)
+
+// ByCodes retries errors with the given errorx codes
+func ByCodes(codes ...int) Classifier {
+	set := make(map[int]struct{}, len(codes))
+	for _, code := range codes {
+		set[code] = struct{}{}
+	}
+	return func(err error) bool {
+		if err == nil || errors.Is(err, context.Canceled) {
+			return false
+		}
+		_, ok := set[errorx.Code(err)]
+		return ok
+	}
+}
+
+// ByReasons retries errors with the given errorx reasons
+func ByReasons(reasons ...string) Classifier {
+	set := make(map[string]struct{}, len(reasons))
+	for _, reason := range reasons {
+		set[reason] = struct{}{}
+	}
+	return func(err error) bool {
+		if err == nil || errors.Is(err, context.Canceled) {
+			return false
+		}
+		_, ok := set[errorx.Reason(err)]
+		return ok
+	}
+}
+
+// ByGRPCCodes retries errors with the given gRPC codes,
+// errorx errors are converted by their http code
+func ByGRPCCodes(grpcCodes ...codes.Code) Classifier {
+	set := make(map[codes.Code]struct{}, len(grpcCodes))
+	for _, code := range grpcCodes {
+		set[code] = struct{}{}
+	}
+	return func(err error) bool {
+		if err == nil || errors.Is(err, context.Canceled) {
+			return false
+		}
+		code := errorx.ToGRPCCode(errorx.Code(err))
+		if gs, ok := status.FromError(err); ok {
+			code = gs.Code()
+		}
+		_, ok := set[code]
+		return ok
+	}
+}
+
+// Any retries if any of the classifiers does
+func Any(classifiers ...Classifier) Classifier {
+	return func(err error) bool {
+		for _, classifier := range classifiers {
+			if classifier(err) {
+				return true
+			}
+		}
+		return false
+	}
+}
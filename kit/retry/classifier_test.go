@@ -0,0 +1,41 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sado0823/go-kitx/errorx"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifier(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		assert.True(t, DefaultClassifier(errorx.ServiceUnavailable("", "")))
+		assert.True(t, DefaultClassifier(errorx.New(429, "", "")))
+		assert.True(t, DefaultClassifier(status.Error(codes.Unavailable, "")))
+		assert.True(t, DefaultClassifier(status.Error(codes.DeadlineExceeded, "")))
+		assert.False(t, DefaultClassifier(errorx.BadRequest("", "")))
+		assert.False(t, DefaultClassifier(errorx.InternalServer("", "")))
+		assert.False(t, DefaultClassifier(status.Error(codes.InvalidArgument, "")))
+		assert.False(t, DefaultClassifier(context.Canceled))
+		assert.False(t, DefaultClassifier(nil))
+	})
+
+	t.Run("reasons", func(t *testing.T) {
+		classifier := ByReasons("LOCKED")
+		assert.True(t, classifier(errorx.Conflict("LOCKED", "")))
+		assert.True(t, classifier(errorx.Conflict("LOCKED", "").WithCause(errors.New("cause"))))
+		assert.False(t, classifier(errorx.Conflict("OTHER", "")))
+	})
+
+	t.Run("grpc codes", func(t *testing.T) {
+		classifier := ByGRPCCodes(codes.Aborted)
+		assert.True(t, classifier(status.Error(codes.Aborted, "")))
+		assert.True(t, classifier(errorx.Conflict("", "")))
+		assert.False(t, classifier(status.Error(codes.Internal, "")))
+	})
+}
@@ -27,9 +27,13 @@ func init() {
 
 type (
 	options struct {
-		limit       int
-		minWaitTime time.Duration
-		maxWaitTime time.Duration
+		limit          int
+		minWaitTime    time.Duration
+		maxWaitTime    time.Duration
+		classifier     Classifier
+		attemptTimeout time.Duration
+		budget         *Budget
+		hedgeDelay     time.Duration
 	}
 
 	Option func(*options)
@@ -62,7 +66,45 @@ func WithMax(max time.Duration) Option {
 	}
 }
 
+// WithClassifier set which errors are retryable, all errors are retried by default
+func WithClassifier(classifier Classifier) Option {
+	return func(o *options) {
+		o.classifier = classifier
+	}
+}
+
+// WithAttemptTimeout set timeout of each attempt, an attempt timed out is always retryable
+func WithAttemptTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.attemptTimeout = timeout
+	}
+}
+
+// WithBudget set the retry budget, it should be shared by all calls to the same backend
+func WithBudget(budget *Budget) Option {
+	return func(o *options) {
+		o.budget = budget
+	}
+}
+
+// WithHedge send another attempt if no response after delay, without waiting for the previous one,
+// the first success wins and the others are canceled. at most limit extra attempts are sent,
+// use it for idempotent requests only
+func WithHedge(delay time.Duration) Option {
+	return func(o *options) {
+		o.hedgeDelay = delay
+	}
+}
+
 func Func(ctx context.Context, subject string, fn func(ctx context.Context) error, opts ...Option) (err error) {
+	_, err = Do(ctx, func(ctx context.Context) (interface{}, error) {
+		return nil, fn(ctx)
+	}, opts...)
+	return err
+}
+
+// Do is like Func, but returns the response of the successful attempt
+func Do(ctx context.Context, fn func(ctx context.Context) (interface{}, error), opts ...Option) (interface{}, error) {
 	o := &options{
 		limit:       defaultMaxRetries,
 		minWaitTime: defaultMinWaitTime,
@@ -72,13 +114,23 @@ func Func(ctx context.Context, subject string, fn func(ctx context.Context) erro
 		op(o)
 	}
 
-	for attempt := 0; attempt <= o.limit; attempt++ {
-		err = fn(ctx)
+	if o.budget != nil {
+		o.budget.deposit()
+	}
+	if o.hedgeDelay > 0 {
+		return o.hedge(ctx, fn)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, timedOut, err := o.call(ctx, fn)
 		if err == nil {
-			break
+			return resp, nil
 		}
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return nil, ctx.Err()
+		}
+		if attempt >= o.limit || !o.retryable(err, timedOut) || !o.allow() {
+			return nil, err
 		}
 
 		backoff := jitterBackoff(o.minWaitTime, o.maxWaitTime, attempt)
@@ -88,11 +140,106 @@ func Func(ctx context.Context, subject string, fn func(ctx context.Context) erro
 		case <-t.C:
 		case <-ctx.Done():
 			t.Stop()
-			return ctx.Err()
+			return nil, ctx.Err()
 		}
 	}
+}
 
-	return err
+func (o *options) hedge(ctx context.Context, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	type result struct {
+		resp     interface{}
+		err      error
+		timedOut bool
+	}
+
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		// buffered, attempts left behind won't block
+		results  = make(chan result, o.limit+1)
+		sent     int
+		inflight int
+		lastErr  error
+	)
+	send := func() {
+		sent++
+		inflight++
+		go func() {
+			resp, timedOut, err := o.call(hedgeCtx, fn)
+			results <- result{resp: resp, err: err, timedOut: timedOut}
+		}()
+	}
+
+	send()
+	timer := time.NewTimer(o.hedgeDelay)
+	defer timer.Stop()
+	resetTimer := func() {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(o.hedgeDelay)
+	}
+
+	for {
+		select {
+		case <-timer.C:
+			if sent <= o.limit && o.allow() {
+				send()
+				timer.Reset(o.hedgeDelay)
+			}
+		case r := <-results:
+			inflight--
+			if r.err == nil {
+				return r.resp, nil
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = r.err
+			if !o.retryable(r.err, r.timedOut) {
+				return nil, r.err
+			}
+			// failed fast, don't wait for the delay
+			if sent <= o.limit && o.allow() {
+				send()
+				resetTimer()
+			} else if inflight == 0 {
+				return nil, lastErr
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (o *options) call(ctx context.Context, fn func(ctx context.Context) (interface{}, error)) (interface{}, bool, error) {
+	if o.attemptTimeout <= 0 {
+		resp, err := fn(ctx)
+		return resp, false, err
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, o.attemptTimeout)
+	defer cancel()
+	resp, err := fn(attemptCtx)
+	return resp, err != nil && ctx.Err() == nil && attemptCtx.Err() == context.DeadlineExceeded, err
+}
+
+func (o *options) retryable(err error, timedOut bool) bool {
+	if timedOut || o.classifier == nil {
+		return true
+	}
+	return o.classifier(err)
+}
+
+func (o *options) allow() bool {
+	if o.budget == nil {
+		return true
+	}
+	return o.budget.withdraw()
 }
 
 // https://aws.amazon.com/cn/blogs/architecture/exponential-backoff-and-jitter/
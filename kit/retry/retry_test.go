@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/sado0823/go-kitx/errorx"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -95,3 +98,103 @@ func Test_jitter(t *testing.T) {
 	})
 
 }
+
+func Test_Do(t *testing.T) {
+	t.Run("classifier", func(t *testing.T) {
+		var tries int
+		_, err := Do(context.Background(), func(ctx context.Context) (interface{}, error) {
+			tries++
+			return nil, errorx.BadRequest("", "")
+		}, WithClassifier(DefaultClassifier), WithMin(time.Millisecond))
+		assert.True(t, errorx.IsBadRequest(err))
+		assert.Equal(t, 1, tries)
+
+		tries = 0
+		resp, err := Do(context.Background(), func(ctx context.Context) (interface{}, error) {
+			tries++
+			if tries < 3 {
+				return nil, errorx.ServiceUnavailable("", "")
+			}
+			return "ok", nil
+		}, WithClassifier(DefaultClassifier), WithMin(time.Millisecond))
+		assert.Nil(t, err)
+		assert.Equal(t, "ok", resp)
+		assert.Equal(t, 3, tries)
+	})
+
+	t.Run("attempt timeout", func(t *testing.T) {
+		var tries int
+		resp, err := Do(context.Background(), func(ctx context.Context) (interface{}, error) {
+			tries++
+			if tries == 1 {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return "ok", nil
+		}, WithAttemptTimeout(time.Millisecond*20), WithClassifier(DefaultClassifier), WithMin(time.Millisecond))
+		assert.Nil(t, err)
+		assert.Equal(t, "ok", resp)
+		assert.Equal(t, 2, tries)
+	})
+
+	t.Run("budget", func(t *testing.T) {
+		var (
+			tries  int
+			budget = NewBudget(0.5, 2)
+			fn     = func(ctx context.Context) (interface{}, error) {
+				tries++
+				return nil, errors.New("budget")
+			}
+		)
+		// 2 retries in bucket at start
+		_, err := Do(context.Background(), fn, WithBudget(budget), WithLimit(5), WithMin(time.Millisecond), WithMax(time.Millisecond*2))
+		assert.NotNil(t, err)
+		assert.Equal(t, 3, tries)
+
+		// only the deposit of this request left
+		tries = 0
+		_, err = Do(context.Background(), fn, WithBudget(budget), WithLimit(5), WithMin(time.Millisecond), WithMax(time.Millisecond*2))
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, tries)
+
+		// two deposits make a retry
+		tries = 0
+		_, err = Do(context.Background(), fn, WithBudget(budget), WithLimit(5), WithMin(time.Millisecond), WithMax(time.Millisecond*2))
+		assert.NotNil(t, err)
+		assert.Equal(t, 2, tries)
+	})
+
+	t.Run("hedge", func(t *testing.T) {
+		var tries int32
+		start := time.Now()
+		resp, err := Do(context.Background(), func(ctx context.Context) (interface{}, error) {
+			if atomic.AddInt32(&tries, 1) == 1 {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return "hedged", nil
+		}, WithHedge(time.Millisecond*20))
+		assert.Nil(t, err)
+		assert.Equal(t, "hedged", resp)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&tries))
+		assert.True(t, time.Since(start) < time.Second)
+	})
+
+	t.Run("hedge fail fast", func(t *testing.T) {
+		var tries int32
+		_, err := Do(context.Background(), func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&tries, 1)
+			return nil, errorx.ServiceUnavailable("", "")
+		}, WithHedge(time.Second), WithLimit(2), WithClassifier(DefaultClassifier))
+		assert.True(t, errorx.IsServiceUnavailable(err))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&tries))
+
+		tries = 0
+		_, err = Do(context.Background(), func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&tries, 1)
+			return nil, errorx.BadRequest("", "")
+		}, WithHedge(time.Second), WithLimit(2), WithClassifier(DefaultClassifier))
+		assert.True(t, errorx.IsBadRequest(err))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&tries))
+	})
+}
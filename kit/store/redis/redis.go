@@ -14,6 +14,15 @@ const (
 	Nil = rdsV8.Nil
 )
 
+type (
+	PubSub       = rdsV8.PubSub
+	Message      = rdsV8.Message
+	Subscription = rdsV8.Subscription
+	Pipeliner    = rdsV8.Pipeliner
+	Cmder        = rdsV8.Cmder
+	IntCmd       = rdsV8.IntCmd
+)
+
 type (
 	Redis struct {
 		Addr string
@@ -24,6 +33,7 @@ type (
 	}
 	Conn interface {
 		rdsV8.Cmdable
+		Subscribe(ctx context.Context, channels ...string) *rdsV8.PubSub
 	}
 )
 
@@ -69,6 +79,54 @@ func (r *Redis) Eval(ctx context.Context, script string, keys []string, args ...
 	return val, err
 }
 
+func (r *Redis) Publish(ctx context.Context, channel string, message interface{}) (val int64, err error) {
+	err = r.brk.DoWithAcceptable(func() error {
+		conn, err := getConn(r)
+		if err != nil {
+			return err
+		}
+		val, err = conn.Publish(ctx, channel, message).Result()
+		return err
+	}, acceptable)
+	return val, err
+}
+
+func (r *Redis) BitCount(ctx context.Context, key string) (val int64, err error) {
+	err = r.brk.DoWithAcceptable(func() error {
+		conn, err := getConn(r)
+		if err != nil {
+			return err
+		}
+		val, err = conn.BitCount(ctx, key, nil).Result()
+		return err
+	}, acceptable)
+	return val, err
+}
+
+// Pipelined sends the commands queued in fn in one round trip,
+// results are read from the queued commands or the returned Cmders
+func (r *Redis) Pipelined(ctx context.Context, fn func(Pipeliner) error) (cmds []Cmder, err error) {
+	err = r.brk.DoWithAcceptable(func() error {
+		conn, err := getConn(r)
+		if err != nil {
+			return err
+		}
+		cmds, err = conn.Pipelined(ctx, fn)
+		return err
+	}, acceptable)
+	return cmds, err
+}
+
+// Subscribe the given channels, the returned PubSub reconnects and resubscribes by itself,
+// caller should close it when it's no longer needed
+func (r *Redis) Subscribe(ctx context.Context, channels ...string) (*PubSub, error) {
+	conn, err := getConn(r)
+	if err != nil {
+		return nil, err
+	}
+	return conn.Subscribe(ctx, channels...), nil
+}
+
 func (r *Redis) Ping(ctx context.Context) error {
 	return r.brk.DoWithAcceptable(func() error {
 		conn, err := getConn(r)
@@ -0,0 +1,143 @@
+package chash
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/hash"
+	"github.com/sado0823/go-kitx/kit/log"
+	"github.com/sado0823/go-kitx/transport/grpc/resolver/discovery"
+	"github.com/sado0823/go-kitx/transport/selector"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	Name = "consistent_hash"
+	// MetadataKey is the outgoing metadata key read by the default balancer
+	MetadataKey = "x-hash-key"
+)
+
+var logger = log.NewHelper(log.WithFields(log.GetGlobal(), "pkg", "chash"))
+
+type hashKey struct{}
+
+func init() {
+	balancer.Register(NewBuilder(Name, MetadataKey))
+}
+
+// NewBuilder create a consistent hash balancer builder which reads hash key from metadataKey of outgoing metadata,
+// register it with balancer.Register and select it by name to use another metadata key
+func NewBuilder(name, metadataKey string) balancer.Builder {
+	return base.NewBalancerBuilder(name, &chashPickBuilder{metadataKey: metadataKey}, base.Config{HealthCheck: true})
+}
+
+// WithKey set hash key into ctx, it takes precedence over metadata
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKey{}, key)
+}
+
+type chashPickBuilder struct {
+	metadataKey string
+}
+
+func (c *chashPickBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	logger.Debugf("chashPickBuilder: Build called with info: %+v", info)
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	picker := &chashPicker{
+		metadataKey: c.metadataKey,
+		ring:        hash.NewConsistent(),
+		conns:       make(map[string]balancer.SubConn, len(info.ReadySCs)),
+		nodes:       make([]*selector.Node, 0, len(info.ReadySCs)),
+		r:           rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for conn, connInfo := range info.ReadySCs {
+		picker.ring.Add(connInfo.Address.Addr)
+		picker.conns[connInfo.Address.Addr] = conn
+		picker.list = append(picker.list, conn)
+		picker.nodes = append(picker.nodes, selector.NewNode(connInfo.Address.Addr, discovery.ServiceFromAddress(connInfo.Address)))
+	}
+	return picker
+}
+
+type chashPicker struct {
+	metadataKey string
+	ring        hash.Consistent
+	conns       map[string]balancer.SubConn
+	list        []balancer.SubConn
+	nodes       []*selector.Node
+	r           *rand.Rand
+	lock        sync.Mutex
+}
+
+// Pick the node of hash key, requests without a key are spread randomly,
+// with node filters in ctx the ring is walked from the key to the first matched node
+func (c *chashPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	if filters := selector.FromFilterContext(info.Ctx); len(filters) > 0 {
+		return c.pickFiltered(info.Ctx, filters)
+	}
+
+	key, ok := c.key(info.Ctx)
+	if !ok {
+		c.lock.Lock()
+		conn := c.list[c.r.Intn(len(c.list))]
+		c.lock.Unlock()
+		return balancer.PickResult{SubConn: conn}, nil
+	}
+
+	addr, ok := c.ring.Get(key)
+	if !ok {
+		return balancer.PickResult{}, balancer.ErrNoSubConnAvailable
+	}
+	return balancer.PickResult{SubConn: c.conns[addr.(string)]}, nil
+}
+
+func (c *chashPicker) pickFiltered(ctx context.Context, filters []selector.NodeFilter) (balancer.PickResult, error) {
+	matched := selector.Filter(ctx, c.nodes, filters...)
+	if len(matched) == 0 {
+		return balancer.PickResult{}, balancer.ErrNoSubConnAvailable
+	}
+
+	key, ok := c.key(ctx)
+	if !ok {
+		c.lock.Lock()
+		node := matched[c.r.Intn(len(matched))]
+		c.lock.Unlock()
+		return balancer.PickResult{SubConn: c.conns[node.Address()]}, nil
+	}
+
+	addrs := make(map[string]struct{}, len(matched))
+	for _, node := range matched {
+		addrs[node.Address()] = struct{}{}
+	}
+	addr, ok := c.ring.(hash.Walker).GetMatched(key, func(value interface{}) bool {
+		_, ok := addrs[value.(string)]
+		return ok
+	})
+	if !ok {
+		return balancer.PickResult{}, balancer.ErrNoSubConnAvailable
+	}
+	return balancer.PickResult{SubConn: c.conns[addr.(string)]}, nil
+}
+
+func (c *chashPicker) key(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	if key, ok := ctx.Value(hashKey{}).(string); ok && key != "" {
+		return key, true
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if values := md.Get(c.metadataKey); len(values) > 0 && values[0] != "" {
+			return values[0], true
+		}
+	}
+	return "", false
+}
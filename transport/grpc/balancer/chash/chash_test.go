@@ -0,0 +1,129 @@
+package chash
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/sado0823/go-kitx/pkg/stringx"
+	"github.com/sado0823/go-kitx/transport/selector"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+)
+
+type mockClientConn struct {
+	// add random string member to avoid map key equality.
+	id string
+}
+
+func (m mockClientConn) UpdateAddresses(addresses []resolver.Address) {
+}
+
+func (m mockClientConn) Connect() {
+}
+
+func buildPicker(n int) balancer.Picker {
+	ready := make(map[balancer.SubConn]base.SubConnInfo)
+	for i := 0; i < n; i++ {
+		ready[mockClientConn{id: stringx.Rand(6)}] = base.SubConnInfo{
+			Address: resolver.Address{Addr: strconv.Itoa(i)},
+		}
+	}
+	return (&chashPickBuilder{metadataKey: MetadataKey}).Build(base.PickerBuildInfo{ReadySCs: ready})
+}
+
+func TestChashPicker_PickNil(t *testing.T) {
+	_, err := buildPicker(0).Pick(balancer.PickInfo{Ctx: context.Background()})
+	assert.Equal(t, balancer.ErrNoSubConnAvailable, err)
+}
+
+func TestChashPicker_Pick(t *testing.T) {
+	picker := buildPicker(10)
+
+	pick := func(ctx context.Context) balancer.SubConn {
+		result, err := picker.Pick(balancer.PickInfo{FullMethodName: "/", Ctx: ctx})
+		assert.Nil(t, err)
+		assert.NotNil(t, result.SubConn)
+		return result.SubConn
+	}
+
+	t.Run("metadata", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), MetadataKey, "user-1")
+		first := pick(ctx)
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, first, pick(ctx))
+		}
+	})
+
+	t.Run("context value", func(t *testing.T) {
+		ctx := WithKey(context.Background(), "user-2")
+		first := pick(ctx)
+		// context value takes precedence
+		ctx = metadata.AppendToOutgoingContext(ctx, MetadataKey, "user-3")
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, first, pick(ctx))
+		}
+	})
+
+	t.Run("spread", func(t *testing.T) {
+		keyed := make(map[balancer.SubConn]struct{})
+		random := make(map[balancer.SubConn]struct{})
+		for i := 0; i < 1000; i++ {
+			keyed[pick(WithKey(context.Background(), strconv.Itoa(i)))] = struct{}{}
+			random[pick(context.Background())] = struct{}{}
+		}
+		assert.Len(t, keyed, 10)
+		assert.Len(t, random, 10)
+	})
+}
+
+func TestChashPicker_PickWithFilter(t *testing.T) {
+	picker := buildPicker(10)
+	even := func(ctx context.Context, nodes []*selector.Node) []*selector.Node {
+		var matched []*selector.Node
+		for _, node := range nodes {
+			if i, _ := strconv.Atoi(node.Address()); i%2 == 0 {
+				matched = append(matched, node)
+			}
+		}
+		return matched
+	}
+	addrs := make(map[balancer.SubConn]string)
+	for addr, conn := range picker.(*chashPicker).conns {
+		addrs[conn] = addr
+	}
+
+	pick := func(ctx context.Context) int {
+		result, err := picker.Pick(balancer.PickInfo{FullMethodName: "/", Ctx: selector.NewFilterContext(ctx, even)})
+		assert.Nil(t, err)
+		i, _ := strconv.Atoi(addrs[result.SubConn])
+		return i
+	}
+
+	ctx := WithKey(context.Background(), "user-1")
+	first := pick(ctx)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, first, pick(ctx))
+		assert.Equal(t, 0, pick(WithKey(context.Background(), strconv.Itoa(i)))%2)
+		assert.Equal(t, 0, pick(context.Background())%2)
+
+		// keys already routed to a matched node stay on the same node as the unfiltered ring
+		keyCtx := WithKey(context.Background(), strconv.Itoa(i))
+		result, err := picker.Pick(balancer.PickInfo{FullMethodName: "/", Ctx: keyCtx})
+		assert.Nil(t, err)
+		if unfiltered, _ := strconv.Atoi(addrs[result.SubConn]); unfiltered%2 == 0 {
+			assert.Equal(t, unfiltered, pick(keyCtx))
+		}
+	}
+}
+
+func TestNewBuilder(t *testing.T) {
+	assert.NotNil(t, balancer.Get(Name))
+
+	builder := NewBuilder("chash_user", "x-user-id")
+	assert.Equal(t, "chash_user", builder.Name())
+}
@@ -1,16 +1,17 @@
 package p2c
 
 import (
+	"context"
 	"fmt"
-	"math"
 	"math/rand"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/sado0823/go-kitx/kit/log"
 	"github.com/sado0823/go-kitx/pkg/atomicx"
+	"github.com/sado0823/go-kitx/transport/grpc/resolver/discovery"
+	"github.com/sado0823/go-kitx/transport/selector"
 
 	"google.golang.org/grpc/balancer"
 	"google.golang.org/grpc/balancer/base"
@@ -22,14 +23,7 @@ import (
 const (
 	Name = "p2c_EWMA"
 
-	decayTime   = int64(time.Second * 10)
 	logInterval = time.Minute
-
-	initSuccess     = 1000
-	throttleSuccess = initSuccess / 2
-	penalty         = int64(math.MaxInt32)
-	forcePick       = int64(time.Second)
-	pickTimes       = 3
 )
 
 var (
@@ -54,17 +48,24 @@ func (p *p2cPickBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
 		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
 	}
 
-	var conns []*p2cSubConn
+	var (
+		conns []*p2cSubConn
+		nodes = make(map[*selector.Node]*p2cSubConn, len(info.ReadySCs))
+	)
 	for conn, connInfo := range info.ReadySCs {
-		conns = append(conns, &p2cSubConn{
-			addr:        connInfo.Address,
-			conn:        conn,
-			successEWMA: initSuccess,
-		})
+		subConn := &p2cSubConn{
+			addr: connInfo.Address,
+			conn: conn,
+			node: selector.NewNode(connInfo.Address.Addr, discovery.ServiceFromAddress(connInfo.Address)),
+			EWMA: selector.NewEWMA(),
+		}
+		conns = append(conns, subConn)
+		nodes[subConn.node] = subConn
 	}
 
 	return &p2cPicker{
 		conns: conns,
+		nodes: nodes,
 		r:     rand.New(rand.NewSource(time.Now().UnixNano())),
 		stamp: atomicx.NewAtomicDuration(),
 	}
@@ -73,47 +74,27 @@ func (p *p2cPickBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
 
 type p2cPicker struct {
 	conns []*p2cSubConn
+	nodes map[*selector.Node]*p2cSubConn
 	r     *rand.Rand
 	stamp *atomicx.Duration
 	lock  sync.Mutex
 }
 
-func (p *p2cPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+// Pick choose from the conns matched by node filters of the request ctx
+func (p *p2cPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	conns := p.filter(info.Ctx)
+
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	var chosen *p2cSubConn
-	switch len(p.conns) {
-	case 0: // 没有节点, 直接报错
+	// 没有节点, 直接报错
+	if len(conns) == 0 {
 		return balancer.PickResult{}, balancer.ErrNoSubConnAvailable
-	case 1: // 一个节点, 直接返回
-		chosen = p.choose(p.conns[0], nil)
-	case 2: // 两个节点, 返回负载最低的节点
-		chosen = p.choose(p.conns[0], p.conns[1])
-	default: // 多个节点, 最多经常三次计算, 选择合适的节点
-		var node1, node2 *p2cSubConn
-		// 三次随机选择节点
-		for i := 0; i < pickTimes; i++ {
-			a := p.r.Intn(len(p.conns))
-			b := p.r.Intn(len(p.conns) - 1)
-			if b >= a {
-				// 防止出现相同节点
-				b++
-			}
-			node1 = p.conns[a]
-			node2 = p.conns[b]
-
-			// 选出一次符合要求的节点则停止
-			if node1.healthy() && node2.healthy() {
-				break
-			}
-		}
-
-		chosen = p.choose(node1, node2)
 	}
 
-	atomic.AddInt64(&chosen.inFlight, 1)
-	atomic.AddInt64(&chosen.requests, 1)
+	chosen := conns[selector.P2C(p.r, len(conns), func(i int) *selector.EWMA {
+		return conns[i].EWMA
+	})]
 
 	return balancer.PickResult{
 		SubConn: chosen.conn,
@@ -121,48 +102,37 @@ func (p *p2cPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
 	}, nil
 }
 
-func (p *p2cPicker) buildDoneFunc(c *p2cSubConn) func(info balancer.DoneInfo) {
-	start := int64(time.Since(initTime))
-	return func(info balancer.DoneInfo) {
-		// 正在处理的请求数-1
-		atomic.AddInt64(&c.inFlight, -1)
-
-		// 计算相对时间
-		now := time.Since(initTime)
-		last := atomic.SwapInt64(&c.lastLag, int64(now))
-		td := int64(now) - last
-		if td < 0 {
-			td = 0
-		}
-
-		// 牛顿冷却定律的衰减模型, 确定ewma中的β值, β = 1/e^(k*△t)
-		beta := math.Exp(float64(-td) / float64(decayTime))
-		lag := int64(now) - start
-		if lag < 0 {
-			lag = 0
-		}
-		olag := atomic.LoadUint64(&c.lagEWMA)
-		if olag == 0 {
-			beta = 0
-		}
+func (p *p2cPicker) filter(ctx context.Context) []*p2cSubConn {
+	filters := selector.FromFilterContext(ctx)
+	if len(filters) == 0 {
+		return p.conns
+	}
 
-		// 指数加权平均算法 vt = vt-1 * β + vt * (1 - β)
-		// 存储当前lagEWMA
-		atomic.StoreUint64(&c.lagEWMA, uint64(float64(olag)*beta+float64(lag)*(1-beta)))
+	nodes := make([]*selector.Node, 0, len(p.conns))
+	for _, conn := range p.conns {
+		nodes = append(nodes, conn.node)
+	}
+	matched := selector.Filter(ctx, nodes, filters...)
+	conns := make([]*p2cSubConn, 0, len(matched))
+	for _, node := range matched {
+		conns = append(conns, p.nodes[node])
+	}
+	return conns
+}
 
-		success := initSuccess
+func (p *p2cPicker) buildDoneFunc(c *p2cSubConn) func(info balancer.DoneInfo) {
+	done := c.Start()
+	return func(info balancer.DoneInfo) {
+		var failed bool
 		if info.Err != nil {
 			switch status.Code(info.Err) {
 			case codes.DeadlineExceeded, codes.Internal, codes.Unavailable, codes.DataLoss:
-				success = 0
+				failed = true
 			}
 		}
+		done(failed)
 
-		oldSuccess := atomic.LoadUint64(&c.successEWMA)
-		// 指数加权平均算法 vt = vt-1 * β + vt * (1 - β)
-		// 存储当前successEWMA
-		atomic.StoreUint64(&c.successEWMA, uint64(float64(oldSuccess)*beta+float64(success)*(1-beta)))
-
+		now := time.Since(initTime)
 		stamp := p.stamp.Load()
 		if now-stamp >= logInterval {
 			if p.stamp.CompareAndSwap(stamp, now) {
@@ -180,61 +150,16 @@ func (p *p2cPicker) logStats() {
 
 	for _, conn := range p.conns {
 		stats = append(stats, fmt.Sprintf("conn: %s, load: %d, reqs: %d",
-			conn.addr.Addr, conn.load(), atomic.SwapInt64(&conn.requests, 0)))
+			conn.addr.Addr, conn.Load(), conn.Requests()))
 	}
 
 	logger.Debugf("%s", strings.Join(stats, "; "))
 }
 
-func (p *p2cPicker) choose(c1, c2 *p2cSubConn) *p2cSubConn {
-	start := int64(time.Since(initTime))
-	if c2 == nil {
-		atomic.StoreInt64(&c1.pickTime, start)
-		return c1
-	}
-
-	// 优先选择负载低的
-	if c1.load() > c2.load() {
-		c1, c2 = c2, c1
-	}
-
-	// 选择响应快的
-	// 如果在超时时间内节点没有被选中过, 则选择该节点
-	pick := atomic.LoadInt64(&c2.pickTime)
-	if start-pick > forcePick && atomic.CompareAndSwapInt64(&c2.pickTime, pick, start) {
-		return c2
-	}
-
-	atomic.StoreInt64(&c1.pickTime, start)
-	return c1
-}
-
 type p2cSubConn struct {
 	addr resolver.Address
 	conn balancer.SubConn
+	node *selector.Node
 
-	lagEWMA uint64 // 请求耗时, 计算后的ewma
-
-	inFlight    int64  // 节点拥塞度, 正在处理的请求
-	successEWMA uint64 // 一段时间内此连接的健康状态, 计算后的ewma
-	requests    int64  // 请求量
-
-	lastLag  int64 // 上一次请求耗时, 用于计算ewma
-	pickTime int64 // 上一次选择的时间时间戳
-}
-
-func (s *p2cSubConn) healthy() bool {
-	return atomic.LoadUint64(&s.successEWMA) > throttleSuccess
-}
-
-// load = lagEWMA * inFlight
-func (s *p2cSubConn) load() int64 {
-	lag := int64(math.Sqrt(float64(atomic.LoadUint64(&s.lagEWMA) + 1)))
-	load := lag * (atomic.LoadInt64(&s.inFlight) + 1)
-	if load == 0 {
-		// penalty是初始化没有数据时的惩罚值, 在没有被选过的情况下, 会强制选择一次
-		return penalty
-	}
-
-	return load
+	*selector.EWMA
 }
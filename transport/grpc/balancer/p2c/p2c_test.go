@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/sado0823/go-kitx/pkg/stringx"
+	"github.com/sado0823/go-kitx/transport/selector"
 	"google.golang.org/grpc/balancer"
 	"google.golang.org/grpc/balancer/base"
 	"google.golang.org/grpc/codes"
@@ -101,7 +102,7 @@ func TestP2cPicker_Pick(t *testing.T) {
 			dist := make(map[string]int)
 			conns := picker.(*p2cPicker).conns
 			for _, conn := range conns {
-				dist[conn.addr.Addr] = int(conn.requests)
+				dist[conn.addr.Addr] = int(conn.Requests())
 			}
 
 			entropy := calcEntropy(dist)
@@ -149,3 +150,53 @@ func calcEntropy(m map[string]int) float64 {
 
 	return entropy / math.Log2(float64(len(m)))
 }
+
+func TestP2cPicker_PickWithFilter(t *testing.T) {
+	ready := make(map[balancer.SubConn]base.SubConnInfo)
+	for i := 0; i < 3; i++ {
+		ready[mockClientConn{id: stringx.Rand(6)}] = base.SubConnInfo{
+			Address: resolver.Address{Addr: strconv.Itoa(i)},
+		}
+	}
+	picker := new(p2cPickBuilder).Build(base.PickerBuildInfo{ReadySCs: ready})
+
+	only := func(addr string) selector.NodeFilter {
+		return func(ctx context.Context, nodes []*selector.Node) []*selector.Node {
+			var matched []*selector.Node
+			for _, node := range nodes {
+				if node.Address() == addr {
+					matched = append(matched, node)
+				}
+			}
+			return matched
+		}
+	}
+
+	ctx := selector.NewFilterContext(context.Background(), only("1"))
+	for i := 0; i < 100; i++ {
+		result, err := picker.Pick(balancer.PickInfo{FullMethodName: "/", Ctx: ctx})
+		assert.Nil(t, err)
+		assert.Equal(t, "1", addrOf(picker, result.SubConn))
+		result.Done(balancer.DoneInfo{})
+	}
+
+	// fallback to all
+	ctx = selector.NewFilterContext(context.Background(), only("3"))
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		result, err := picker.Pick(balancer.PickInfo{FullMethodName: "/", Ctx: ctx})
+		assert.Nil(t, err)
+		seen[addrOf(picker, result.SubConn)] = true
+		result.Done(balancer.DoneInfo{})
+	}
+	assert.True(t, len(seen) > 1)
+}
+
+func addrOf(picker balancer.Picker, conn balancer.SubConn) string {
+	for _, c := range picker.(*p2cPicker).conns {
+		if c.conn == conn {
+			return c.addr.Addr
+		}
+	}
+	return ""
+}
@@ -8,15 +8,19 @@ import (
 
 	"github.com/sado0823/go-kitx/kit/registry"
 	"github.com/sado0823/go-kitx/transport"
+	_ "github.com/sado0823/go-kitx/transport/grpc/balancer/chash"
 	"github.com/sado0823/go-kitx/transport/grpc/balancer/p2c"
 	_ "github.com/sado0823/go-kitx/transport/grpc/resolver/direct"
 	"github.com/sado0823/go-kitx/transport/grpc/resolver/discovery"
 	"github.com/sado0823/go-kitx/transport/pbchain"
+	"github.com/sado0823/go-kitx/transport/selector"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	grpcinsecure "google.golang.org/grpc/credentials/insecure"
 	grpcmd "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type (
@@ -27,10 +31,13 @@ type (
 		tlsConfig *tls.Config
 		timeout   time.Duration
 		discovery registry.Discovery
+		balancer  string
+		filters   []selector.NodeFilter
 		pbchain   []pbchain.Middleware
 
-		unaryInts []grpc.UnaryClientInterceptor
-		grpcOpts  []grpc.DialOption
+		unaryInts  []grpc.UnaryClientInterceptor
+		streamInts []grpc.StreamClientInterceptor
+		grpcOpts   []grpc.DialOption
 	}
 )
 
@@ -58,6 +65,21 @@ func WithClientDiscovery(d registry.Discovery) ClientOption {
 	}
 }
 
+// WithClientBalancer set the balancer by name, e.g. p2c.Name (default) or chash.Name
+func WithClientBalancer(name string) ClientOption {
+	return func(o *client) {
+		o.balancer = name
+	}
+}
+
+// WithClientNodeFilter set filters of discovered instances, they are applied per request by the balancer,
+// all instances are used if none matches
+func WithClientNodeFilter(filters ...selector.NodeFilter) ClientOption {
+	return func(o *client) {
+		o.filters = filters
+	}
+}
+
 func WithClientTLSConfig(c *tls.Config) ClientOption {
 	return func(o *client) {
 		o.tlsConfig = c
@@ -70,6 +92,12 @@ func WithClientUnaryInterceptor(in ...grpc.UnaryClientInterceptor) ClientOption
 	}
 }
 
+func WithClientStreamInterceptor(in ...grpc.StreamClientInterceptor) ClientOption {
+	return func(o *client) {
+		o.streamInts = in
+	}
+}
+
 func WithClientDialOptions(opts ...grpc.DialOption) ClientOption {
 	return func(o *client) {
 		o.grpcOpts = opts
@@ -86,7 +114,8 @@ func DialInsecure(ctx context.Context, opts ...ClientOption) (*grpc.ClientConn,
 
 func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.ClientConn, error) {
 	opt := &client{
-		timeout: time.Second * 2,
+		timeout:  time.Second * 2,
+		balancer: p2c.Name,
 	}
 
 	for _, op := range opts {
@@ -94,13 +123,19 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 	}
 
 	ints := []grpc.UnaryClientInterceptor{
-		unaryClientInterceptor(opt.pbchain, opt.timeout),
+		unaryClientInterceptor(opt.pbchain, opt.timeout, opt.filters),
 	}
 	ints = append(ints, opt.unaryInts...)
 
+	streamInts := []grpc.StreamClientInterceptor{
+		streamClientInterceptor(opt.pbchain, opt.filters),
+	}
+	streamInts = append(streamInts, opt.streamInts...)
+
 	grpcOpts := []grpc.DialOption{
-		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{"%s":{}}]}`, p2c.Name)),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{"%s":{}}]}`, opt.balancer)),
 		grpc.WithChainUnaryInterceptor(ints...),
+		grpc.WithChainStreamInterceptor(streamInts...),
 	}
 
 	if opt.discovery != nil {
@@ -121,8 +156,9 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 	return grpc.DialContext(ctx, opt.endpoint, grpcOpts...)
 }
 
-func unaryClientInterceptor(ms []pbchain.Middleware, timeout time.Duration) grpc.UnaryClientInterceptor {
+func unaryClientInterceptor(ms []pbchain.Middleware, timeout time.Duration, filters []selector.NodeFilter) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = selector.NewFilterContext(ctx, filters...)
 		ctx = transport.NewClientContext(ctx, &Transport{
 			endpoint:  cc.Target(),
 			operation: method,
@@ -134,16 +170,7 @@ func unaryClientInterceptor(ms []pbchain.Middleware, timeout time.Duration) grpc
 			defer cancel()
 		}
 		h := func(ctx context.Context, req interface{}) (interface{}, error) {
-			if tr, ok := transport.FromClientContext(ctx); ok {
-				header := tr.RequestHeader()
-				keys := header.Keys()
-				keyvals := make([]string, 0, len(keys))
-				for _, k := range keys {
-					keyvals = append(keyvals, k, header.Get(k))
-				}
-				ctx = grpcmd.AppendToOutgoingContext(ctx, keyvals...)
-			}
-			return reply, invoker(ctx, method, req, reply, cc, opts...)
+			return reply, invoker(outgoingContext(ctx), method, req, reply, cc, opts...)
 		}
 		if len(ms) > 0 {
 			h = pbchain.Chain(ms...)(h)
@@ -152,3 +179,63 @@ func unaryClientInterceptor(ms []pbchain.Middleware, timeout time.Duration) grpc
 		return err
 	}
 }
+
+// streamClientInterceptor runs the pbchain once when the stream is opened, so that request headers
+// set by the chain are sent, then once per message sent or received, the client timeout is not applied to streams
+func streamClientInterceptor(ms []pbchain.Middleware, filters []selector.NodeFilter) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = selector.NewFilterContext(ctx, filters...)
+		ctx = transport.NewClientContext(ctx, &Transport{
+			endpoint:  cc.Target(),
+			operation: method,
+			reqHeader: headerCarrier{},
+		})
+		streamCtx := ctx
+		h := func(ctx context.Context, req interface{}) (interface{}, error) {
+			// the per message chain sees the ctx built by the chain
+			streamCtx = ctx
+			return streamer(outgoingContext(ctx), desc, cc, method, opts...)
+		}
+		if len(ms) > 0 {
+			h = pbchain.Chain(ms...)(h)
+		}
+		cs, err := h(pbchain.NewStreamContext(ctx, pbchain.StreamOpen), nil)
+		if err != nil {
+			return nil, err
+		}
+		stream, ok := cs.(grpc.ClientStream)
+		if !ok {
+			return nil, status.Errorf(codes.Internal, "pbchain returned %T instead of the client stream", cs)
+		}
+		return &wrappedClientStream{ClientStream: stream, ctx: streamCtx, ms: ms}, nil
+	}
+}
+
+type wrappedClientStream struct {
+	grpc.ClientStream
+	ctx context.Context
+	ms  []pbchain.Middleware
+}
+
+func (w *wrappedClientStream) SendMsg(m interface{}) error {
+	return pbchain.Send(w.ctx, w.ms, m, w.ClientStream.SendMsg)
+}
+
+func (w *wrappedClientStream) RecvMsg(m interface{}) error {
+	return pbchain.Recv(w.ctx, w.ms, m, w.ClientStream.RecvMsg)
+}
+
+// outgoingContext appends the request header of the client transport to the outgoing metadata
+func outgoingContext(ctx context.Context) context.Context {
+	tr, ok := transport.FromClientContext(ctx)
+	if !ok {
+		return ctx
+	}
+	header := tr.RequestHeader()
+	keys := header.Keys()
+	keyvals := make([]string, 0, len(keys))
+	for _, k := range keys {
+		keyvals = append(keyvals, k, header.Get(k))
+	}
+	return grpcmd.AppendToOutgoingContext(ctx, keyvals...)
+}
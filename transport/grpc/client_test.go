@@ -0,0 +1,275 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/internal/test/pbhelloworld"
+	"github.com/sado0823/go-kitx/kit/registry"
+	"github.com/sado0823/go-kitx/transport"
+	"github.com/sado0823/go-kitx/transport/grpc/balancer/chash"
+	"github.com/sado0823/go-kitx/transport/pbchain"
+	"github.com/sado0823/go-kitx/transport/selector"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDial_WithClientBalancer(t *testing.T) {
+	server := NewServer(WithServerAddress("127.0.0.1:0"))
+	pbhelloworld.RegisterGreeterServer(server, &testHelloServer{})
+	u, err := server.Endpoint()
+	assert.Nil(t, err)
+
+	go func() {
+		_ = server.Start(context.Background())
+	}()
+	defer server.Stop(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	conn, err := DialInsecure(ctx,
+		WithClientEndpoint(u.Host),
+		WithClientBalancer(chash.Name),
+		WithClientDialOptions(grpc.WithBlock()),
+	)
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	_, err = pbhelloworld.NewGreeterClient(conn).SayHello(chash.WithKey(context.Background(), "user-1"), &pbhelloworld.HelloRequest{Name: "chash"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+type namedHelloServer struct {
+	testHelloServer
+	name string
+}
+
+func (s *namedHelloServer) SayHello(ctx context.Context, req *pbhelloworld.HelloRequest) (*pbhelloworld.HelloReply, error) {
+	return &pbhelloworld.HelloReply{Message: s.name}, nil
+}
+
+func TestDial_WithClientNodeFilter(t *testing.T) {
+	dis := registry.NewMemory()
+	for _, name := range []string{"canary", "stable"} {
+		server := NewServer(WithServerAddress("127.0.0.1:0"))
+		pbhelloworld.RegisterGreeterServer(server, &namedHelloServer{name: name})
+		u, err := server.Endpoint()
+		assert.Nil(t, err)
+		go func() {
+			_ = server.Start(context.Background())
+		}()
+		defer server.Stop(context.Background())
+
+		assert.Nil(t, dis.Register(context.Background(), &registry.Service{
+			ID:        name,
+			Name:      "helloworld",
+			Metadata:  map[string]string{"canary": strconv.FormatBool(name == "canary")},
+			Endpoints: []string{u.String()},
+		}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	conn, err := DialInsecure(ctx,
+		WithClientEndpoint("discovery:///helloworld"),
+		WithClientDiscovery(dis),
+		WithClientNodeFilter(selector.Canary("canary", "true", 20)),
+	)
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	const total = 1000
+	client := pbhelloworld.NewGreeterClient(conn)
+	counts := make(map[string]int)
+	for i := 0; i < total; i++ {
+		reply, err := client.SayHello(context.Background(), &pbhelloworld.HelloRequest{Name: "canary"}, grpc.WaitForReady(true))
+		assert.Nil(t, err)
+		counts[reply.GetMessage()]++
+	}
+	assert.InDelta(t, 0.2, float64(counts["canary"])/total, 0.06)
+	assert.Equal(t, total, counts["canary"]+counts["stable"])
+}
+
+type testStreamServer struct {
+	testHelloServer
+}
+
+func (s *testStreamServer) SayHelloStream(stream pbhelloworld.Greeter_SayHelloStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = stream.Send(&pbhelloworld.HelloReply{Message: "hello " + req.Name}); err != nil {
+			return err
+		}
+	}
+}
+
+// recorder records the stream directions the pbchain runs for
+type recorder struct {
+	lock sync.Mutex
+	seen []string
+}
+
+func (r *recorder) middleware(next pbchain.Handler) pbchain.Handler {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		if d, ok := pbchain.FromStreamContext(ctx); ok {
+			r.lock.Lock()
+			r.seen = append(r.seen, d.String())
+			r.lock.Unlock()
+		}
+		return next(ctx, req)
+	}
+}
+
+func (r *recorder) directions() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string(nil), r.seen...)
+}
+
+func TestDial_Stream(t *testing.T) {
+	var (
+		serverRec = &recorder{}
+		clientRec = &recorder{}
+		header    = make(chan string, 1)
+	)
+	server := NewServer(
+		WithServerAddress("127.0.0.1:0"),
+		WithServerPBChain(serverRec.middleware, func(next pbchain.Handler) pbchain.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				if d, _ := pbchain.FromStreamContext(ctx); d == pbchain.StreamOpen {
+					tr, _ := transport.FromServerContext(ctx)
+					header <- tr.RequestHeader().Get("x-md-trace")
+				}
+				if r, ok := req.(*pbhelloworld.HelloRequest); ok && r.Name == "bad" {
+					return nil, status.Error(codes.InvalidArgument, "bad name")
+				}
+				return next(ctx, req)
+			}
+		}),
+	)
+	pbhelloworld.RegisterGreeterServer(server, &testStreamServer{})
+	u, err := server.Endpoint()
+	assert.Nil(t, err)
+
+	go func() {
+		_ = server.Start(context.Background())
+	}()
+	defer server.Stop(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	conn, err := DialInsecure(ctx,
+		WithClientEndpoint(u.Host),
+		WithClientDialOptions(grpc.WithBlock()),
+		WithClientPBChain(clientRec.middleware, func(next pbchain.Handler) pbchain.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				if tr, ok := transport.FromClientContext(ctx); ok {
+					tr.RequestHeader().Set("x-md-trace", "2233")
+				}
+				return next(ctx, req)
+			}
+		}),
+	)
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	stream, err := pbhelloworld.NewGreeterClient(conn).SayHelloStream(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, stream.Send(&pbhelloworld.HelloRequest{Name: "stream"}))
+	reply, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, "hello stream", reply.Message)
+	assert.Equal(t, "2233", <-header)
+
+	// rejected when received by the server
+	assert.Nil(t, stream.Send(&pbhelloworld.HelloRequest{Name: "bad"}))
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	assert.Equal(t, []string{"open", "send", "recv", "send"}, clientRec.directions())
+	assert.Equal(t, []string{"open", "recv", "send", "recv"}, serverRec.directions())
+}
+
+type chainKey struct{}
+
+// chainValue sets a ctx value when the stream opens and records the value seen by each message
+func chainValue(value string, seen chan<- string) pbchain.Middleware {
+	return func(next pbchain.Handler) pbchain.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			d, _ := pbchain.FromStreamContext(ctx)
+			if d == pbchain.StreamOpen {
+				return next(context.WithValue(ctx, chainKey{}, value), req)
+			}
+			v, _ := ctx.Value(chainKey{}).(string)
+			seen <- d.String() + ":" + v
+			return next(ctx, req)
+		}
+	}
+}
+
+type ctxStreamServer struct {
+	testHelloServer
+	value chan string
+}
+
+func (s *ctxStreamServer) SayHelloStream(stream pbhelloworld.Greeter_SayHelloStreamServer) error {
+	v, _ := stream.Context().Value(chainKey{}).(string)
+	s.value <- v
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	return stream.Send(&pbhelloworld.HelloReply{Message: "hello " + req.Name})
+}
+
+func TestDial_StreamChainContext(t *testing.T) {
+	var (
+		serverSeen = make(chan string, 2)
+		clientSeen = make(chan string, 2)
+		srv        = &ctxStreamServer{value: make(chan string, 1)}
+	)
+	server := NewServer(WithServerAddress("127.0.0.1:0"), WithServerPBChain(chainValue("server", serverSeen)))
+	pbhelloworld.RegisterGreeterServer(server, srv)
+	u, err := server.Endpoint()
+	assert.Nil(t, err)
+
+	go func() {
+		_ = server.Start(context.Background())
+	}()
+	defer server.Stop(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	conn, err := DialInsecure(ctx,
+		WithClientEndpoint(u.Host),
+		WithClientDialOptions(grpc.WithBlock()),
+		WithClientPBChain(chainValue("client", clientSeen)),
+	)
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	stream, err := pbhelloworld.NewGreeterClient(conn).SayHelloStream(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, stream.Send(&pbhelloworld.HelloRequest{Name: "stream"}))
+	_, err = stream.Recv()
+	assert.Nil(t, err)
+
+	assert.Equal(t, "server", <-srv.value)
+	assert.Equal(t, "recv:server", <-serverSeen)
+	assert.Equal(t, "send:server", <-serverSeen)
+	assert.Equal(t, "send:client", <-clientSeen)
+	assert.Equal(t, "recv:client", <-clientSeen)
+}
@@ -54,6 +54,7 @@ func (s *Server) defaultUnaryServerInterceptor() grpc.UnaryServerInterceptor {
 type wrappedStream struct {
 	grpc.ServerStream
 	ctx context.Context
+	ms  []pbchain.Middleware
 }
 
 func NewWrappedStream(ctx context.Context, stream grpc.ServerStream) grpc.ServerStream {
@@ -67,25 +68,50 @@ func (w *wrappedStream) Context() context.Context {
 	return w.ctx
 }
 
-// streamServerInterceptor is a gRPC stream server interceptor
+func (w *wrappedStream) SendMsg(m interface{}) error {
+	return pbchain.Send(w.ctx, w.ms, m, w.ServerStream.SendMsg)
+}
+
+func (w *wrappedStream) RecvMsg(m interface{}) error {
+	return pbchain.Recv(w.ctx, w.ms, m, w.ServerStream.RecvMsg)
+}
+
+// streamServerInterceptor is a gRPC stream server interceptor,
+// the pbchain runs once around the whole stream and once per message sent or received
 func (s *Server) defaultStreamServerInterceptor() grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		ctx, cancel := contextx.Merge(ss.Context(), s.ctx)
 		defer cancel()
 		md, _ := grpcmd.FromIncomingContext(ctx)
 		replyHeader := grpcmd.MD{}
-		ctx = transport.NewServerContext(ctx, &Transport{
-			endpoint:    s.endpoint.String(),
+		tr := &Transport{
 			operation:   info.FullMethod,
 			reqHeader:   headerCarrier(md),
 			replyHeader: headerCarrier(replyHeader),
-		})
+		}
+		if s.endpoint != nil {
+			tr.endpoint = s.endpoint.String()
+		}
+		ctx = transport.NewServerContext(ctx, tr)
 
-		ws := NewWrappedStream(ctx, ss)
+		ws := &wrappedStream{
+			ServerStream: ss,
+			ctx:          ctx,
+			ms:           s.pbchain.Match(tr.Operation()),
+		}
 
-		err := handler(srv, ws)
+		handlerFn := func(ctx context.Context, req interface{}) (interface{}, error) {
+			// the handler and the per message chain see the ctx built by the chain
+			ws.ctx = ctx
+			return nil, handler(srv, ws)
+		}
+		if len(ws.ms) > 0 {
+			handlerFn = pbchain.Chain(ws.ms...)(handlerFn)
+		}
+
+		_, err := handlerFn(pbchain.NewStreamContext(ctx, pbchain.StreamOpen), nil)
 		if len(replyHeader) > 0 {
-			_ = grpc.SetHeader(ctx, replyHeader)
+			_ = ss.SetHeader(replyHeader)
 		}
 		return err
 	}
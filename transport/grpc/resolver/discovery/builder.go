@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/sado0823/go-kitx/kit/registry"
+	"github.com/sado0823/go-kitx/transport/selector"
 
 	googleResolver "google.golang.org/grpc/resolver"
 )
@@ -22,6 +23,7 @@ type (
 		discovery registry.Discovery
 		timeout   time.Duration
 		insecure  bool
+		filters   []selector.NodeFilter
 	}
 )
 
@@ -37,6 +39,14 @@ func WithInsecure(insecure bool) Option {
 	}
 }
 
+// WithNodeFilter set filters of instances, they are applied when instances change,
+// so stateful filters like selector.Canary don't fit, pass them per request by selector.NewFilterContext
+func WithNodeFilter(filters ...selector.NodeFilter) Option {
+	return func(b *builder) {
+		b.filters = filters
+	}
+}
+
 //	NewBuilder example discovery://<authority>/name
 func NewBuilder(dis registry.Discovery, opts ...Option) googleResolver.Builder {
 	b := &builder{
@@ -92,6 +102,7 @@ func (b *builder) Build(target googleResolver.Target, cc googleResolver.ClientCo
 		watcher:    watchRes.w,
 		conn:       cc,
 		insecure:   b.insecure,
+		filters:    b.filters,
 	}
 
 	// when init app, make sure have right endpoint
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/sado0823/go-kitx/kit/log"
 	"github.com/sado0823/go-kitx/kit/registry"
 	"github.com/sado0823/go-kitx/transport/internal/endpoint"
+	"github.com/sado0823/go-kitx/transport/selector"
 
 	"google.golang.org/grpc/attributes"
 	googleResolver "google.golang.org/grpc/resolver"
@@ -21,6 +23,7 @@ type resolver struct {
 	conn       googleResolver.ClientConn
 
 	insecure bool
+	filters  []selector.NodeFilter
 }
 
 func (r *resolver) ResolveNow(googleResolver.ResolveNowOptions) {
@@ -97,7 +100,7 @@ func (r *resolver) watch() {
 }
 
 func (r *resolver) update(svcs []*registry.Service) error {
-	addrs := make([]googleResolver.Address, 0)
+	nodes := make([]*selector.Node, 0, len(svcs))
 	endpoints := make(map[string]struct{})
 	for _, svc := range svcs {
 		parseEndpoint, err := endpoint.ParseEndpoint(svc.Endpoints, endpoint.Scheme("grpc", !r.insecure))
@@ -113,12 +116,17 @@ func (r *resolver) update(svcs []*registry.Service) error {
 			continue
 		}
 		endpoints[parseEndpoint] = struct{}{}
+		nodes = append(nodes, selector.NewNode(parseEndpoint, svc))
+	}
+
+	addrs := make([]googleResolver.Address, 0, len(nodes))
+	for _, node := range selector.Filter(r.ctx, nodes, r.filters...) {
 		addr := googleResolver.Address{
-			Addr:       parseEndpoint,
-			ServerName: svc.Name,
-			Attributes: parseAttributes(svc.Metadata),
+			Addr:       node.Address(),
+			ServerName: node.Service().Name,
+			Attributes: parseAttributes(node.Service().Metadata),
 		}
-		addr.Attributes = addr.Attributes.WithValue("rawServiceInstance", r.insecure)
+		addr.Attributes = addr.Attributes.WithValue("rawServiceInstance", r.insecure).WithValue(serviceKey{}, serviceAttr{node.Service()})
 		addrs = append(addrs, addr)
 	}
 	if len(addrs) == 0 {
@@ -131,6 +139,26 @@ func (r *resolver) update(svcs []*registry.Service) error {
 	})
 }
 
+type serviceKey struct{}
+
+// serviceAttr compares by content, so an unchanged instance keeps its SubConn across updates
+type serviceAttr struct {
+	svc *registry.Service
+}
+
+func (s serviceAttr) Equal(o interface{}) bool {
+	other, ok := o.(serviceAttr)
+	return ok && reflect.DeepEqual(s.svc, other.svc)
+}
+
+// ServiceFromAddress returns the service instance addr is resolved from, nil if addr is not from discovery
+func ServiceFromAddress(addr googleResolver.Address) *registry.Service {
+	if attr, ok := addr.Attributes.Value(serviceKey{}).(serviceAttr); ok {
+		return attr.svc
+	}
+	return nil
+}
+
 func parseAttributes(md map[string]string) *attributes.Attributes {
 	var a *attributes.Attributes
 	for k, v := range md {
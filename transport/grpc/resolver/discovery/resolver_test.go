@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sado0823/go-kitx/kit/registry"
+	"github.com/sado0823/go-kitx/transport/selector"
+
+	"github.com/stretchr/testify/assert"
+	googleResolver "google.golang.org/grpc/resolver"
+)
+
+type mockConn struct {
+	googleResolver.ClientConn
+	state googleResolver.State
+}
+
+func (m *mockConn) UpdateState(state googleResolver.State) error {
+	m.state = state
+	return nil
+}
+
+func addrsOf(state googleResolver.State) []string {
+	var addrs []string
+	for _, addr := range state.Addresses {
+		addrs = append(addrs, addr.Addr)
+	}
+	return addrs
+}
+
+func TestResolver_UpdateWithFilter(t *testing.T) {
+	var (
+		conn = &mockConn{}
+		r    = &resolver{
+			ctx:      context.Background(),
+			conn:     conn,
+			insecure: true,
+			filters:  []selector.NodeFilter{selector.Version("v2")},
+		}
+		svcs = []*registry.Service{
+			{Name: "test", Version: "v1", Endpoints: []string{"grpc://127.0.0.1:9001"}},
+			{Name: "test", Version: "v2", Endpoints: []string{"grpc://127.0.0.1:9002"}},
+			{Name: "test", Version: "v2", Endpoints: []string{"http://127.0.0.1:8003"}},
+		}
+	)
+
+	assert.Nil(t, r.update(svcs))
+	assert.Equal(t, []string{"127.0.0.1:9002"}, addrsOf(conn.state))
+
+	// fallback to all
+	assert.Nil(t, r.update(svcs[:1]))
+	assert.Equal(t, []string{"127.0.0.1:9001"}, addrsOf(conn.state))
+}
+
+func TestResolver_UpdateWithService(t *testing.T) {
+	var (
+		conn = &mockConn{}
+		r    = &resolver{ctx: context.Background(), conn: conn, insecure: true}
+		svc  = &registry.Service{Name: "test", Version: "v1", Endpoints: []string{"grpc://127.0.0.1:9001"}}
+	)
+
+	assert.Nil(t, r.update([]*registry.Service{svc}))
+	addr := conn.state.Addresses[0]
+	assert.Equal(t, svc, ServiceFromAddress(addr))
+	assert.Nil(t, ServiceFromAddress(googleResolver.Address{Addr: "127.0.0.1:9001"}))
+
+	// an unchanged instance resolves to an equal address
+	copied := *svc
+	assert.Nil(t, r.update([]*registry.Service{&copied}))
+	assert.True(t, addr.Equal(conn.state.Addresses[0]))
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"net/http"
+	"time"
 )
 
 // CallOption configures a Call before it starts or extracts information from
@@ -20,6 +21,10 @@ type callInfo struct {
 	contentType  string
 	operation    string
 	pathTemplate string
+	timeout      time.Duration
+	idempotent   bool
+	retry        RetryPolicy
+	encoder      EncodeRequestFunc
 }
 
 // EmptyCallOption does not alter the Call configuration.
@@ -110,3 +115,122 @@ func (o HeaderCallOption) after(c *callInfo, cs *csAttempt) {
 		*o.header = cs.res.Header
 	}
 }
+
+// Timeout sets the timeout of every attempt of the call, it overrides the client timeout
+func Timeout(d time.Duration) CallOption {
+	return TimeoutCallOption{Timeout: d}
+}
+
+// TimeoutCallOption is set timeout for client call
+type TimeoutCallOption struct {
+	EmptyCallOption
+	Timeout time.Duration
+}
+
+func (o TimeoutCallOption) before(c *callInfo) error {
+	c.timeout = o.Timeout
+	return nil
+}
+
+// Idempotent marks the call as idempotent, only idempotent calls are retried
+func Idempotent(idempotent bool) CallOption {
+	return IdempotentCallOption{Idempotent: idempotent}
+}
+
+// IdempotentCallOption is set idempotency for client call
+type IdempotentCallOption struct {
+	EmptyCallOption
+	Idempotent bool
+}
+
+func (o IdempotentCallOption) before(c *callInfo) error {
+	c.idempotent = o.Idempotent
+	return nil
+}
+
+// RetryPolicy retries idempotent calls on connection errors, attempt timeouts and Codes
+type RetryPolicy struct {
+	// Attempts is the max attempts including the first one
+	Attempts int
+	// Backoff is the wait before the first retry, it's doubled after every retry
+	Backoff time.Duration
+	// Codes are the http status codes to retry, default is 502, 503 and 504
+	Codes []int
+}
+
+var defaultRetryCodes = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+func (p RetryPolicy) retryable(code int) bool {
+	codes := p.Codes
+	if len(codes) == 0 {
+		codes = defaultRetryCodes
+	}
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Retry sets the retry policy of the call, it takes effect only if the call is Idempotent
+func Retry(policy RetryPolicy) CallOption {
+	return RetryCallOption{Policy: policy}
+}
+
+// RetryCallOption is set retry policy for client call
+type RetryCallOption struct {
+	EmptyCallOption
+	Policy RetryPolicy
+}
+
+func (o RetryCallOption) before(c *callInfo) error {
+	c.retry = o.Policy
+	return nil
+}
+
+// BodyEncoder encodes the request body of the call instead of the client request encoder
+func BodyEncoder(encoder EncodeRequestFunc) CallOption {
+	return BodyEncoderCallOption{Encoder: encoder}
+}
+
+// BodyEncoderCallOption is set request encoder for client call
+type BodyEncoderCallOption struct {
+	EmptyCallOption
+	Encoder EncodeRequestFunc
+}
+
+func (o BodyEncoderCallOption) before(c *callInfo) error {
+	c.encoder = o.Encoder
+	return nil
+}
+
+type (
+	// MethodCallOptions are the call options of generated clients by operation
+	MethodCallOptions map[string][]CallOption
+
+	// MethodOption sets call options of a method of generated clients
+	MethodOption func(MethodCallOptions)
+)
+
+// WithMethodCallOption appends opts to every call of operation, they override the defaults
+// generated from proto options, e.g. http.WithMethodCallOption(OperationGreeterSayHello, http.Timeout(time.Second))
+func WithMethodCallOption(operation string, opts ...CallOption) MethodOption {
+	return func(m MethodCallOptions) {
+		m[operation] = append(m[operation], opts...)
+	}
+}
+
+func NewMethodCallOptions(opts ...MethodOption) MethodCallOptions {
+	m := make(MethodCallOptions)
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Get returns the call options of operation, appending to it doesn't modify m
+func (m MethodCallOptions) Get(operation string) []CallOption {
+	opts := m[operation]
+	return opts[:len(opts):len(opts)]
+}
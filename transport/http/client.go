@@ -11,10 +11,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/kit/registry"
 	"github.com/sado0823/go-kitx/transport"
 	"github.com/sado0823/go-kitx/transport/pbchain"
+	"github.com/sado0823/go-kitx/transport/selector"
 )
 
+// nodeFailedTimeout is how long a node is skipped after a connection error
+const nodeFailedTimeout = time.Second * 5
+
 type (
 	ClientOption func(op *Client)
 
@@ -33,8 +39,13 @@ type (
 		transport       http.RoundTripper
 		pbchain         []pbchain.Middleware
 		insecure        bool
+		discovery       registry.Discovery
+		balancer        selector.Balancer
+		nodeFilters     []selector.NodeFilter
 
-		httpClient *http.Client
+		resolver     *resolver
+		httpClient   *http.Client
+		streamClient *http.Client
 	}
 )
 
@@ -92,6 +103,28 @@ func WithClientTLSConfig(c *tls.Config) ClientOption {
 	}
 }
 
+// WithClientDiscovery resolve endpoint like discovery:///name with d
+func WithClientDiscovery(d registry.Discovery) ClientOption {
+	return func(o *Client) {
+		o.discovery = d
+	}
+}
+
+// WithClientBalancer set the balancer of discovered nodes, default is selector.NewP2C()
+func WithClientBalancer(b selector.Balancer) ClientOption {
+	return func(o *Client) {
+		o.balancer = b
+	}
+}
+
+// WithClientNodeFilter set filters of discovered nodes, they are applied per request,
+// all nodes are used if none matches
+func WithClientNodeFilter(filters ...selector.NodeFilter) ClientOption {
+	return func(o *Client) {
+		o.nodeFilters = filters
+	}
+}
+
 func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 	client := &Client{
 		ctx:             ctx,
@@ -100,6 +133,7 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 		responseDecoder: ResponseDecoder,
 		errorDecoder:    ErrorDecoder,
 		transport:       http.DefaultTransport,
+		balancer:        selector.NewP2C(),
 	}
 	for _, opt := range opts {
 		opt(client)
@@ -117,10 +151,26 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 	}
 	client.host = urlV.Host
 	client.scheme = urlV.Scheme
+	if urlV.Scheme == discoveryScheme {
+		if client.discovery == nil {
+			return nil, fmt.Errorf("discovery is required for endpoint: %s", client.endpoint)
+		}
+		if client.resolver, err = newResolver(ctx, client.discovery, urlV, client.insecure, client.timeout); err != nil {
+			return nil, err
+		}
+		client.scheme = "http"
+		if !client.insecure {
+			client.scheme = "https"
+		}
+	}
 	client.httpClient = &http.Client{
 		Timeout:   client.timeout,
 		Transport: client.transport,
 	}
+	// streams and calls with their own timeout last as long as their context
+	client.streamClient = &http.Client{
+		Transport: client.transport,
+	}
 
 	return client, nil
 }
@@ -138,6 +188,9 @@ func parseClientEndpoint(endpoint string, insecure bool) (*url.URL, error) {
 }
 
 func (c *Client) Close() error {
+	if c.resolver != nil {
+		c.resolver.Close()
+	}
 	return nil
 }
 
@@ -149,15 +202,29 @@ func (c *Client) Invoke(ctx context.Context, method, path string, args, reply in
 		}
 	}
 
+	ctx, request, err := c.newRequest(ctx, method, path, args, call)
+	if err != nil {
+		return err
+	}
+
+	return c.invoke(ctx, request, args, reply, call, opts...)
+}
+
+// newRequest encodes args as the body and returns the request with the client transport context
+func (c *Client) newRequest(ctx context.Context, method, path string, args interface{}, call callInfo) (context.Context, *http.Request, error) {
 	var (
 		contentTypeStr string
 		body           io.Reader
 	)
 
 	if args != nil {
-		encoder, err := c.requestEncoder(ctx, call.contentType, args)
+		encode := c.requestEncoder
+		if call.encoder != nil {
+			encode = call.encoder
+		}
+		encoder, err := encode(ctx, call.contentType, args)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 		contentTypeStr = call.contentType
 		body = bytes.NewReader(encoder)
@@ -166,7 +233,7 @@ func (c *Client) Invoke(ctx context.Context, method, path string, args, reply in
 	url := fmt.Sprintf("%s://%s%s", c.scheme, c.host, path)
 	request, err := http.NewRequest(strings.ToUpper(method), url, body)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	if contentTypeStr != "" {
 		request.Header.Set("Content-Type", contentTypeStr)
@@ -182,27 +249,31 @@ func (c *Client) Invoke(ctx context.Context, method, path string, args, reply in
 		request:      request,
 		pathTemplate: call.pathTemplate,
 	})
-
-	return c.invoke(ctx, request, args, reply, call, opts...)
+	return ctx, request, nil
 }
 
 func (c *Client) invoke(ctx context.Context, req *http.Request, args, reply interface{}, call callInfo, opts ...CallOption) error {
 	h := func(ctx context.Context, args interface{}) (interface{}, error) {
-		resp, err := c.do(req.WithContext(ctx))
-		if resp != nil {
-			cs := csAttempt{res: resp}
-			for _, o := range opts {
-				o.after(&call, &cs)
+		backoff := call.retry.Backoff
+		for attempt := 1; ; attempt++ {
+			retryable, err := c.attempt(ctx, req, reply, call, attempt > 1, opts...)
+			if err == nil {
+				return reply, nil
+			}
+			if !call.idempotent || attempt >= call.retry.Attempts || !retryable || ctx.Err() != nil {
+				return nil, err
+			}
+			if backoff > 0 {
+				timer := time.NewTimer(backoff)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return nil, err
+				case <-timer.C:
+				}
+				backoff *= 2
 			}
 		}
-		if err != nil {
-			return nil, err
-		}
-		defer resp.Body.Close()
-		if err = c.responseDecoder(ctx, resp, reply); err != nil {
-			return nil, err
-		}
-		return reply, nil
 	}
 
 	if len(c.pbchain) > 0 {
@@ -213,6 +284,42 @@ func (c *Client) invoke(ctx context.Context, req *http.Request, args, reply inte
 	return err
 }
 
+// attempt sends req once within the call timeout, retryable reports whether the error
+// is a connection error, an attempt timeout or a status code to retry
+func (c *Client) attempt(ctx context.Context, req *http.Request, reply interface{}, call callInfo, retry bool, opts ...CallOption) (retryable bool, err error) {
+	client := c.httpClient
+	if call.timeout > 0 {
+		// the client timeout would cut the call timeout
+		client = c.streamClient
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, call.timeout)
+		defer cancel()
+	}
+
+	req = req.WithContext(ctx)
+	if retry && req.GetBody != nil {
+		if req.Body, err = req.GetBody(); err != nil {
+			return false, err
+		}
+	}
+
+	resp, err := c.do(client, req)
+	if resp != nil {
+		cs := csAttempt{res: resp}
+		for _, o := range opts {
+			o.after(&call, &cs)
+		}
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		if resp == nil {
+			return true, err
+		}
+		return call.retry.retryable(errorx.Code(err)), err
+	}
+	return false, c.responseDecoder(ctx, resp, reply)
+}
+
 // Do send an HTTP request and decodes the body of response into target.
 // returns an error (of type *Error) if the response status code is not 2xx.
 func (c *Client) Do(req *http.Request, opts ...CallOption) (*http.Response, error) {
@@ -223,11 +330,43 @@ func (c *Client) Do(req *http.Request, opts ...CallOption) (*http.Response, erro
 		}
 	}
 
-	return c.do(req)
+	return c.do(c.httpClient, req)
+}
+
+func (c *Client) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if c.resolver == nil {
+		return c.send(client, req)
+	}
+
+	node, done, err := selector.Select(req.Context(), c.balancer, c.resolver.Nodes(), c.nodeFilters...)
+	if err != nil {
+		return nil, errorx.ServiceUnavailable("NODE_NOT_FOUND", err.Error())
+	}
+
+	// the request may be retried on other nodes, keep the original one untouched
+	req = req.Clone(req.Context())
+	req.URL.Scheme = c.scheme
+	req.URL.Host = node.Address()
+	req.Host = ""
+
+	response, err := c.send(client, req)
+	var failed bool
+	if response == nil && err != nil {
+		failed = true
+		// connection error, skip the node for a while
+		if req.Context().Err() == nil {
+			node.MarkFailed(nodeFailedTimeout)
+		}
+	} else if errorx.Code(err) >= http.StatusInternalServerError {
+		failed = true
+	}
+	done(req.Context(), selector.DoneInfo{Err: err, Failed: failed})
+
+	return response, err
 }
 
-func (c *Client) do(req *http.Request) (*http.Response, error) {
-	response, err := c.httpClient.Do(req)
+func (c *Client) send(client *http.Client, req *http.Request) (*http.Response, error) {
+	response, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
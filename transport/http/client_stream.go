@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	stdjson "encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/pkg/encoding"
+	"github.com/sado0823/go-kitx/pkg/encoding/json"
+	"github.com/sado0823/go-kitx/transport/pbchain"
+)
+
+// ClientStream reads the messages of a server stream, written as server-sent events
+// or newline-delimited json, the format is told by the response Content-Type.
+// It must be closed once no longer needed
+type ClientStream struct {
+	ctx    context.Context
+	res    *http.Response
+	rd     *bufio.Reader
+	codec  encoding.Codec
+	ms     []pbchain.Middleware
+	ndjson bool
+}
+
+// Stream sends the request and returns the stream of the response, the pbchain runs once with
+// pbchain.StreamOpen when it's sent, then with pbchain.StreamRecv for every message received.
+// The client timeout doesn't apply, the stream lasts as long as ctx
+func (c *Client) Stream(ctx context.Context, method, path string, args interface{}, format StreamFormat, opts ...CallOption) (*ClientStream, error) {
+	call := defaultCallInfo(path)
+	for _, opt := range opts {
+		if err := opt.before(&call); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, request, err := c.newRequest(ctx, method, path, args, call)
+	if err != nil {
+		return nil, err
+	}
+	if format == StreamNDJSON {
+		request.Header.Set("Accept", ndjsonContentType)
+	} else {
+		request.Header.Set("Accept", sseContentType)
+	}
+
+	streamCtx := ctx
+	h := func(ctx context.Context, args interface{}) (interface{}, error) {
+		// messages received run the pbchain with the ctx it built
+		streamCtx = ctx
+		resp, err := c.do(c.streamClient, request.WithContext(ctx))
+		if resp != nil {
+			cs := csAttempt{res: resp}
+			for _, o := range opts {
+				o.after(&call, &cs)
+			}
+		}
+		if err != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, err
+		}
+		return resp, nil
+	}
+	if len(c.pbchain) > 0 {
+		h = pbchain.Chain(c.pbchain...)(h)
+	}
+
+	resp, err := h(pbchain.NewStreamContext(ctx, pbchain.StreamOpen), args)
+	if err != nil {
+		return nil, err
+	}
+	res := resp.(*http.Response)
+	return &ClientStream{
+		ctx:    streamCtx,
+		res:    res,
+		rd:     bufio.NewReader(res.Body),
+		codec:  encoding.GetCodec(json.Name),
+		ms:     c.pbchain,
+		ndjson: strings.HasPrefix(res.Header.Get("Content-Type"), ndjsonContentType),
+	}, nil
+}
+
+// Header returns the response header
+func (s *ClientStream) Header() http.Header {
+	return s.res.Header
+}
+
+// RecvMsg reads the next message into m, it returns io.EOF once the stream ends,
+// or the error sent by the server
+func (s *ClientStream) RecvMsg(m interface{}) error {
+	return pbchain.Recv(s.ctx, s.ms, m, s.recv)
+}
+
+// Close closes the response body
+func (s *ClientStream) Close() error {
+	return s.res.Body.Close()
+}
+
+func (s *ClientStream) recv(m interface{}) error {
+	if s.ndjson {
+		return s.recvLine(m)
+	}
+	return s.recvEvent(m)
+}
+
+func (s *ClientStream) recvLine(m interface{}) error {
+	for {
+		line, err := s.rd.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		var envelope struct {
+			Result stdjson.RawMessage `json:"result"`
+			Error  stdjson.RawMessage `json:"error"`
+		}
+		if err = stdjson.Unmarshal(line, &envelope); err != nil {
+			return err
+		}
+		if envelope.Error != nil {
+			return s.decodeError(envelope.Error)
+		}
+		return s.codec.Unmarshal(envelope.Result, m)
+	}
+}
+
+func (s *ClientStream) recvEvent(m interface{}) error {
+	var (
+		event string
+		data  [][]byte
+	)
+	for {
+		line, err := s.rd.ReadBytes('\n')
+		if err != nil && len(line) == 0 {
+			return err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			// dispatch the event
+			if data == nil {
+				event = ""
+				continue
+			}
+			payload := bytes.Join(data, []byte("\n"))
+			if event == "error" {
+				return s.decodeError(payload)
+			}
+			return s.codec.Unmarshal(payload, m)
+		}
+		if line[0] == ':' {
+			// comment, e.g. heartbeat
+			continue
+		}
+		field, value := line, []byte(nil)
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], bytes.TrimPrefix(line[i+1:], []byte(" "))
+		}
+		switch string(field) {
+		case "event":
+			event = string(value)
+		case "data":
+			data = append(data, value)
+		}
+	}
+}
+
+func (s *ClientStream) decodeError(data []byte) error {
+	se := new(errorx.Error)
+	if err := s.codec.Unmarshal(data, se); err != nil {
+		return errorx.Newf(errorx.UnknownCode, errorx.UnknownReason, "%s", data).WithCause(err)
+	}
+	return se
+}
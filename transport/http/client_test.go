@@ -0,0 +1,259 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/kit/registry"
+	"github.com/sado0823/go-kitx/transport/selector"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDiscovery struct {
+	ch chan []*registry.Service
+}
+
+func (m *mockDiscovery) Get(ctx context.Context, name string) ([]*registry.Service, error) {
+	return nil, nil
+}
+
+func (m *mockDiscovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	return &mockWatcher{ctx: ctx, ch: m.ch}, nil
+}
+
+type mockWatcher struct {
+	ctx context.Context
+	ch  chan []*registry.Service
+}
+
+func (m *mockWatcher) Next() ([]*registry.Service, error) {
+	select {
+	case svcs := <-m.ch:
+		return svcs, nil
+	case <-m.ctx.Done():
+		return nil, m.ctx.Err()
+	}
+}
+
+func (m *mockWatcher) Stop() error {
+	return nil
+}
+
+func newTestServer(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"` + name + `"}`))
+	}))
+}
+
+func newService(id string, srv *httptest.Server) *registry.Service {
+	return &registry.Service{ID: id, Name: "test", Endpoints: []string{"grpc://127.0.0.1:9000", srv.URL}}
+}
+
+func TestClient_Discovery(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		srv1 = newTestServer("srv1")
+		srv2 = newTestServer("srv2")
+		dis  = &mockDiscovery{ch: make(chan []*registry.Service, 1)}
+	)
+	defer srv1.Close()
+	defer srv2.Close()
+
+	_, err := NewClient(ctx, WithClientEndpoint("discovery:///test"))
+	assert.NotNil(t, err)
+
+	dis.ch <- []*registry.Service{newService("1", srv1), newService("2", srv2)}
+	client, err := NewClient(ctx,
+		WithClientEndpoint("discovery:///test"),
+		WithClientDiscovery(dis),
+		WithClientBalancer(selector.NewRoundRobin()),
+	)
+	assert.Nil(t, err)
+	defer client.Close()
+
+	invoke := func() string {
+		reply := make(map[string]string)
+		if err := client.Invoke(ctx, http.MethodGet, "/hello", nil, &reply); err != nil {
+			return err.Error()
+		}
+		return reply["name"]
+	}
+
+	picked := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		picked[invoke()]++
+	}
+	assert.Equal(t, map[string]int{"srv1": 5, "srv2": 5}, picked)
+
+	// failed node is skipped
+	srv2.Close()
+	assert.NotEqual(t, invoke(), invoke())
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "srv1", invoke())
+	}
+
+	// updated by watcher
+	srv3 := newTestServer("srv3")
+	defer srv3.Close()
+	dis.ch <- []*registry.Service{newService("3", srv3)}
+	assert.Eventually(t, func() bool {
+		return invoke() == "srv3"
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestClient_DiscoveryFirstCheck(t *testing.T) {
+	dis := &mockDiscovery{ch: make(chan []*registry.Service, 1)}
+	dis.ch <- []*registry.Service{{ID: "1", Endpoints: []string{"grpc://127.0.0.1:9000"}}}
+	_, err := NewClient(context.Background(), WithClientEndpoint("discovery:///test"), WithClientDiscovery(dis))
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "no valid endpoint"))
+
+	// timeout
+	_, err = NewClient(context.Background(), WithClientEndpoint("discovery:///test"), WithClientDiscovery(dis), WithClientTimeout(time.Millisecond*50))
+	assert.NotNil(t, err)
+}
+
+func TestClient_DiscoveryNodeFilter(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		srv1 = newTestServer("srv1")
+		srv2 = newTestServer("srv2")
+		dis  = &mockDiscovery{ch: make(chan []*registry.Service, 1)}
+	)
+	defer srv1.Close()
+	defer srv2.Close()
+
+	v2 := newService("2", srv2)
+	v2.Version = "v2"
+	dis.ch <- []*registry.Service{newService("1", srv1), v2}
+	client, err := NewClient(ctx,
+		WithClientEndpoint("discovery:///test"),
+		WithClientDiscovery(dis),
+		WithClientNodeFilter(selector.Version("v2")),
+	)
+	assert.Nil(t, err)
+	defer client.Close()
+
+	for i := 0; i < 10; i++ {
+		reply := make(map[string]string)
+		assert.Nil(t, client.Invoke(ctx, http.MethodGet, "/hello", nil, &reply))
+		assert.Equal(t, "srv2", reply["name"])
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		calls int32
+		fails int32 = 2
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		if atomic.AddInt32(&fails, -1) >= 0 {
+			ErrorEncoder(w, r, errorx.ServiceUnavailable("BUSY", "busy"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(ctx, WithClientEndpoint(srv.URL))
+	assert.Nil(t, err)
+	retry := Retry(RetryPolicy{Attempts: 3, Backoff: time.Millisecond})
+
+	// retried with the same body
+	reply := make(map[string]string)
+	assert.Nil(t, client.Invoke(ctx, http.MethodPut, "/put", map[string]string{"name": "kitx"}, &reply, retry, Idempotent(true)))
+	assert.Equal(t, "kitx", reply["name"])
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// not idempotent
+	atomic.StoreInt32(&calls, 0)
+	atomic.StoreInt32(&fails, 1)
+	err = client.Invoke(ctx, http.MethodPost, "/post", map[string]string{}, &reply, retry)
+	assert.True(t, errorx.IsServiceUnavailable(err))
+	assert.Equal(t, "BUSY", errorx.Reason(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// code not to retry
+	atomic.StoreInt32(&calls, 0)
+	atomic.StoreInt32(&fails, 1)
+	err = client.Invoke(ctx, http.MethodGet, "/get", nil, &reply, Retry(RetryPolicy{Attempts: 3, Codes: []int{http.StatusBadGateway}}), Idempotent(true))
+	assert.True(t, errorx.IsServiceUnavailable(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// attempts exhausted
+	atomic.StoreInt32(&calls, 0)
+	atomic.StoreInt32(&fails, 5)
+	err = client.Invoke(ctx, http.MethodGet, "/get", nil, &reply, retry, Idempotent(true))
+	assert.True(t, errorx.IsServiceUnavailable(err))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestClient_CallTimeout(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		calls int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first attempt is slow
+		if atomic.AddInt32(&calls, 1) == 1 {
+			time.Sleep(time.Millisecond * 200)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"kitx"}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(ctx, WithClientEndpoint(srv.URL), WithClientTimeout(time.Millisecond*50))
+	assert.Nil(t, err)
+
+	// the call timeout overrides the client timeout
+	reply := make(map[string]string)
+	assert.Nil(t, client.Invoke(ctx, http.MethodGet, "/get", nil, &reply, Timeout(time.Second)))
+	assert.Equal(t, "kitx", reply["name"])
+
+	// attempt timeout is retried
+	atomic.StoreInt32(&calls, 0)
+	reply = make(map[string]string)
+	assert.Nil(t, client.Invoke(ctx, http.MethodGet, "/get", nil, &reply, Timeout(time.Millisecond*50), Idempotent(true), Retry(RetryPolicy{Attempts: 2})))
+	assert.Equal(t, "kitx", reply["name"])
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestClient_MethodCallOption(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"` + r.Header.Get("Content-Type") + ` ` + string(body) + `"}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(ctx, WithClientEndpoint(srv.URL))
+	assert.Nil(t, err)
+
+	opts := NewMethodCallOptions(WithMethodCallOption("/test/Form", ContentType("text/plain"), BodyEncoder(func(ctx context.Context, contentType string, in interface{}) ([]byte, error) {
+		return []byte(in.(map[string]string)["name"]), nil
+	})))
+	assert.Len(t, opts.Get("/test/Other"), 0)
+
+	reply := make(map[string]string)
+	assert.Nil(t, client.Invoke(ctx, http.MethodPost, "/form", map[string]string{"name": "kitx"}, &reply, append(opts.Get("/test/Form"), Operation("/test/Form"))...))
+	assert.Equal(t, "text/plain kitx", reply["name"])
+
+	// appending doesn't change the options
+	_ = append(opts.Get("/test/Form"), Timeout(time.Second))
+	assert.Len(t, opts.Get("/test/Form"), 2)
+}
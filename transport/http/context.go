@@ -43,6 +43,7 @@ type (
 		String(int, string) error
 		Blob(int, string, []byte) error
 		Stream(int, string, io.Reader) error
+		SSE(...SSEOption) (*SSEWriter, error)
 		Reset(http.ResponseWriter, *http.Request)
 	}
 
@@ -160,6 +161,13 @@ func (c *contextx) Stream(code int, contentType string, rd io.Reader) error {
 	return err
 }
 
+// SSE starts an event stream, the writer should be closed before the handler returns,
+// the heartbeat stops with the request anyway.
+// Requests accepting text/event-stream get the server stream timeout instead of the server timeout
+func (c *contextx) SSE(options ...SSEOption) (*SSEWriter, error) {
+	return NewSSEWriter(c.res, append([]SSEOption{WithSSEContext(c.req.Context())}, options...)...)
+}
+
 func (c *contextx) Reset(res http.ResponseWriter, req *http.Request) {
 	c.w.Reset(res)
 	c.res = res
@@ -0,0 +1,64 @@
+package http_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/internal/test/pbhelloworld"
+	"github.com/sado0823/go-kitx/transport/http"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type greeter struct {
+	pbhelloworld.UnimplementedGreeterServer
+	calls int32
+}
+
+func (g *greeter) SayHello(ctx context.Context, in *pbhelloworld.HelloRequest) (*pbhelloworld.HelloReply, error) {
+	if atomic.AddInt32(&g.calls, 1) == 1 {
+		return nil, errorx.ServiceUnavailable("BUSY", "busy")
+	}
+	if in.Name == "nobody" {
+		return nil, pbhelloworld.ErrorUserNotFound("user %s not found", in.Name)
+	}
+	return &pbhelloworld.HelloReply{Message: "hello " + in.Name}, nil
+}
+
+func TestGeneratedClient(t *testing.T) {
+	var (
+		ctx = context.Background()
+		g   = &greeter{}
+		srv = http.NewServer()
+	)
+	pbhelloworld.RegisterGreeterHTTPServer(srv.Route("/"), g)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	cc, err := http.NewClient(ctx, http.WithClientEndpoint(ts.URL))
+	assert.Nil(t, err)
+	client := pbhelloworld.NewGreeterHTTPClient(cc,
+		http.WithMethodCallOption(pbhelloworld.OperationGreeterSayHello, http.Retry(http.RetryPolicy{Attempts: 2, Backoff: time.Millisecond})),
+	)
+
+	// GET is idempotent, retried on 503
+	reply, err := client.SayHello(ctx, &pbhelloworld.HelloRequest{Name: "kitx"})
+	assert.Nil(t, err)
+	assert.Equal(t, "hello kitx", reply.Message)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&g.calls))
+
+	// typed reason
+	_, err = client.SayHello(ctx, &pbhelloworld.HelloRequest{Name: "nobody"})
+	assert.True(t, pbhelloworld.IsUserNotFound(err))
+	reason, ok := pbhelloworld.ErrorReasonFromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, pbhelloworld.ErrorReason_USER_NOT_FOUND, reason)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&g.calls))
+
+	_, ok = pbhelloworld.ErrorReasonFromError(errorx.BadRequest("OTHER", ""))
+	assert.False(t, ok)
+}
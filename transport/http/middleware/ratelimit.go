@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/log"
+	"github.com/sado0823/go-kitx/kit/ratelimit"
+)
+
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// RateLimit limits requests by key, e.g. client ip or tenant, and sets X-RateLimit-* headers.
+// rejected requests get 429 with Retry-After, requests are let through if limiter fails
+func RateLimit(limiter ratelimit.QuotaLimiter, key func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			quota, err := limiter.Take(r.Context(), key(r))
+			if err != nil {
+				log.Errorf("rate limit take error: %v, let it through", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(quota.Limit))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(quota.Remaining))
+			header.Set(HeaderRateLimitReset, ceilSeconds(quota.ResetAfter))
+			if !quota.Allowed {
+				header.Set(HeaderRetryAfter, ceilSeconds(quota.RetryAfter))
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockQuotaLimiter struct {
+	quota *ratelimit.Quota
+	err   error
+	keys  []string
+}
+
+func (m *mockQuotaLimiter) Take(ctx context.Context, key string) (*ratelimit.Quota, error) {
+	m.keys = append(m.keys, key)
+	return m.quota, m.err
+}
+
+func TestRateLimit(t *testing.T) {
+	newHandler := func(limiter ratelimit.QuotaLimiter) http.Handler {
+		return RateLimit(limiter, func(r *http.Request) string {
+			return r.Header.Get("x-tenant")
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		limiter := &mockQuotaLimiter{quota: &ratelimit.Quota{
+			Allowed:    true,
+			Limit:      10,
+			Remaining:  9,
+			ResetAfter: time.Millisecond * 1500,
+		}}
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		req.Header.Set("x-tenant", "foo")
+		resp := httptest.NewRecorder()
+		newHandler(limiter).ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, []string{"foo"}, limiter.keys)
+		assert.Equal(t, "10", resp.Header().Get(HeaderRateLimitLimit))
+		assert.Equal(t, "9", resp.Header().Get(HeaderRateLimitRemaining))
+		assert.Equal(t, "2", resp.Header().Get(HeaderRateLimitReset))
+		assert.Equal(t, "", resp.Header().Get(HeaderRetryAfter))
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		limiter := &mockQuotaLimiter{quota: &ratelimit.Quota{
+			Limit:      10,
+			RetryAfter: time.Millisecond * 200,
+			ResetAfter: time.Second * 3,
+		}}
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		resp := httptest.NewRecorder()
+		newHandler(limiter).ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Equal(t, "0", resp.Header().Get(HeaderRateLimitRemaining))
+		assert.Equal(t, "3", resp.Header().Get(HeaderRateLimitReset))
+		assert.Equal(t, "1", resp.Header().Get(HeaderRetryAfter))
+	})
+
+	t.Run("limiter error", func(t *testing.T) {
+		limiter := &mockQuotaLimiter{err: errors.New("redis down")}
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		resp := httptest.NewRecorder()
+		newHandler(limiter).ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "", resp.Header().Get(HeaderRateLimitLimit))
+	})
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/pkg/encoding"
+	"github.com/sado0823/go-kitx/pkg/encoding/json"
+)
+
+// NDJSONWriter writes newline-delimited json, every message is wrapped as {"result":...}
+// and an error as {"error":...}, each line is flushed once written.
+// The response header is written along with the first line
+type NDJSONWriter struct {
+	lock    sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+	codec   encoding.Codec
+	started bool
+	closed  bool
+}
+
+// NewNDJSONWriter returns a writer of newline-delimited json
+func NewNDJSONWriter(w http.ResponseWriter) (*NDJSONWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrSSEUnsupported
+	}
+	return &NDJSONWriter{w: w, flusher: flusher, codec: encoding.GetCodec(json.Name)}, nil
+}
+
+// Send writes the message as a line
+func (w *NDJSONWriter) Send(m interface{}) error {
+	return w.writeLine("result", m)
+}
+
+// Error writes the error as a line
+func (w *NDJSONWriter) Error(err error) error {
+	return w.writeLine("error", errorx.FromError(err))
+}
+
+// Start writes the response header and flushes it if it has not been written
+func (w *NDJSONWriter) Start() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.start()
+}
+
+// Started reports whether the response header has been written
+func (w *NDJSONWriter) Started() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.started
+}
+
+func (w *NDJSONWriter) Close() error {
+	w.lock.Lock()
+	w.closed = true
+	w.lock.Unlock()
+	return nil
+}
+
+func (w *NDJSONWriter) writeMsg(m interface{}) error {
+	return w.Send(m)
+}
+
+func (w *NDJSONWriter) writeLine(key string, v interface{}) error {
+	data, err := w.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`{"` + key + `":`)
+	buf.Write(data)
+	buf.WriteString("}\n")
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if err = w.start(); err != nil {
+		return err
+	}
+	if _, err = w.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	w.flusher.Flush()
+	return nil
+}
+
+func (w *NDJSONWriter) start() error {
+	if w.closed {
+		return errStreamClosed
+	}
+	if w.started {
+		return nil
+	}
+	w.started = true
+	w.w.Header().Set("Content-Type", ndjsonContentType)
+	w.w.Header().Set("X-Accel-Buffering", "no")
+	w.w.WriteHeader(http.StatusOK)
+	w.flusher.Flush()
+	return nil
+}
@@ -0,0 +1,22 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ServeOpenAPI serves the spec written by protoc-gen-openapi-kitx on GET path,
+// usually embedded with go:embed. path ending with .json is served as json, otherwise yaml
+func (s *Server) ServeOpenAPI(path string, spec []byte) {
+	contentType := "application/yaml"
+	if strings.HasSuffix(path, ".json") {
+		contentType = "application/json"
+	}
+	modTime := time.Now()
+	s.router.Methods(http.MethodGet, http.MethodHead).Path(path).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		http.ServeContent(w, r, "", modTime, bytes.NewReader(spec))
+	})
+}
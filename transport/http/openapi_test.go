@@ -0,0 +1,37 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_ServeOpenAPI(t *testing.T) {
+	srv := NewServer()
+	srv.ServeOpenAPI("/openapi.yaml", []byte("openapi: 3.0.3\n"))
+	srv.ServeOpenAPI("/openapi.json", []byte(`{"openapi":"3.0.3"}`))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	for path, want := range map[string]string{
+		"/openapi.yaml": "application/yaml",
+		"/openapi.json": "application/json",
+	} {
+		res, err := http.Get(ts.URL + path)
+		assert.Nil(t, err)
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, want, res.Header.Get("Content-Type"))
+		assert.Contains(t, string(body), "3.0.3")
+	}
+
+	res, err := http.Post(ts.URL+"/openapi.yaml", "text/plain", nil)
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.NotEqual(t, http.StatusOK, res.StatusCode)
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/log"
+	"github.com/sado0823/go-kitx/kit/registry"
+	"github.com/sado0823/go-kitx/transport/internal/endpoint"
+	"github.com/sado0823/go-kitx/transport/selector"
+)
+
+const discoveryScheme = "discovery"
+
+// resolver keeps nodes of discovery:///name up to date by watcher
+type resolver struct {
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+	watcher    registry.Watcher
+	insecure   bool
+
+	lock  sync.RWMutex
+	nodes []*selector.Node
+}
+
+func newResolver(ctx context.Context, discovery registry.Discovery, target *url.URL, insecure bool, timeout time.Duration) (*resolver, error) {
+	var (
+		appName                              = strings.TrimPrefix(target.Path, "/")
+		watchCtx, cancelFunc                 = context.WithCancel(ctx)
+		ctxWithTimeout, ctxWithTimeoutCancel = context.WithTimeout(ctx, timeout)
+		done                                 = make(chan error, 1)
+	)
+	defer ctxWithTimeoutCancel()
+
+	r := &resolver{ctx: watchCtx, cancelFunc: cancelFunc, insecure: insecure}
+	go func() {
+		watcher, err := discovery.Watch(watchCtx, appName)
+		if err != nil {
+			done <- err
+			return
+		}
+		r.watcher = watcher
+
+		// when init app, make sure have right endpoint
+		svcs, err := watcher.Next()
+		if err != nil {
+			done <- err
+			return
+		}
+		r.update(svcs)
+		if len(r.Nodes()) == 0 {
+			done <- errors.New("discovery found no valid endpoint at first time")
+			return
+		}
+		done <- nil
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctxWithTimeout.Done():
+		err = fmt.Errorf("discovery create watcher overtime, err: %+v", ctxWithTimeout.Err())
+	}
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("first check err: %+v, app name: %s", err, appName)
+	}
+
+	go r.watch()
+	return r, nil
+}
+
+// Nodes returns current nodes, it must not be modified
+func (r *resolver) Nodes() []*selector.Node {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.nodes
+}
+
+func (r *resolver) Close() {
+	r.cancelFunc()
+	if r.watcher == nil {
+		return
+	}
+	if err := r.watcher.Stop(); err != nil {
+		log.Errorf("http discovery resolver watcher stop err:%+v", err)
+	}
+}
+
+func (r *resolver) watch() {
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		default:
+		}
+		svcs, err := r.watcher.Next()
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			log.Errorf("http discovery resolver watch err:%+v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		r.update(svcs)
+	}
+}
+
+func (r *resolver) update(svcs []*registry.Service) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// keep nodes of the same address, so are their stats
+	existing := make(map[string]*selector.Node, len(r.nodes))
+	for _, node := range r.nodes {
+		existing[node.Address()] = node
+	}
+
+	nodes := make([]*selector.Node, 0, len(svcs))
+	seen := make(map[string]struct{}, len(svcs))
+	for _, svc := range svcs {
+		address, err := endpoint.ParseEndpoint(svc.Endpoints, endpoint.Scheme("http", !r.insecure))
+		if err != nil {
+			log.Errorf("http discovery resolver update err:%+v", err)
+			continue
+		}
+		if address == "" {
+			continue
+		}
+		if _, ok := seen[address]; ok {
+			continue
+		}
+		seen[address] = struct{}{}
+
+		if node, ok := existing[address]; ok {
+			nodes = append(nodes, node.Renew(svc))
+		} else {
+			nodes = append(nodes, selector.NewNode(address, svc))
+		}
+	}
+	if len(nodes) == 0 {
+		log.Warnf("http discovery resolver update found no addr, svcs:%#v", svcs)
+		return
+	}
+
+	r.nodes = nodes
+}
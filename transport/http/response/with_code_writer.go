@@ -1,6 +1,11 @@
 package response
 
-import "net/http"
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
 
 type WithCodeResponseWriter struct {
 	Code   int
@@ -24,3 +29,23 @@ func (w *WithCodeResponseWriter) Write(data []byte) (int, error) {
 func (w *WithCodeResponseWriter) Header() http.Header {
 	return w.Writer.Header()
 }
+
+// Flush sends buffered data to the client, if the underlying writer supports it
+func (w *WithCodeResponseWriter) Flush() {
+	if flusher, ok := w.Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the caller take over the connection, e.g. for websocket upgrades
+func (w *WithCodeResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.Writer.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not implement http.Hijacker")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.Code = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
@@ -1,6 +1,7 @@
 package response
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -53,3 +54,42 @@ func Test_WithCodeResponseWriter(t *testing.T) {
 		assert.Equal(t, string(content), resp.Body.String())
 	})
 }
+
+func Test_WithCodeResponseWriter_Stream(t *testing.T) {
+	t.Run("Flush", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		codeW := &WithCodeResponseWriter{Writer: resp}
+
+		_, err := codeW.Write([]byte("data"))
+		assert.Nil(t, err)
+		codeW.Flush()
+		assert.True(t, resp.Flushed)
+	})
+
+	t.Run("HijackUnsupported", func(t *testing.T) {
+		codeW := &WithCodeResponseWriter{Writer: httptest.NewRecorder()}
+		_, _, err := codeW.Hijack()
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Hijack", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			codeW := &WithCodeResponseWriter{Writer: w}
+			conn, rw, err := codeW.Hijack()
+			assert.Nil(t, err)
+			defer conn.Close()
+
+			assert.Equal(t, http.StatusSwitchingProtocols, codeW.Code)
+			_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+			_ = rw.Flush()
+		}))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL)
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "hijacked", string(body))
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/sado0823/go-kitx/internal/host"
@@ -16,6 +17,7 @@ import (
 	"github.com/sado0823/go-kitx/transport/pbchain"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 )
 
 var (
@@ -38,6 +40,8 @@ type (
 		network     string
 		address     string
 		timeout     time.Duration
+		// streamTimeout applies to websocket, sse and ndjson requests instead of timeout
+		streamTimeout time.Duration
 
 		filters []FilterFunc
 		pbchain pbchain.Matcher
@@ -45,6 +49,7 @@ type (
 		reqDecoder   DecodeRequestFunc
 		respEncoder  EncodeResponseFunc
 		errorEncoder EncodeErrorFunc
+		upgrader     *WebSocketUpgrader
 
 		err error
 	}
@@ -68,6 +73,14 @@ func WithServerTimeout(timeout time.Duration) ServerOption {
 	}
 }
 
+// WithServerStreamTimeout set the timeout of stream requests, i.e. websocket upgrades and
+// requests accepting sse or ndjson, they have no timeout by default
+func WithServerStreamTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.streamTimeout = timeout
+	}
+}
+
 func WithServerPBChain(m ...pbchain.Middleware) ServerOption {
 	return func(o *Server) {
 		o.pbchain.Use(m...)
@@ -129,6 +142,7 @@ func NewServer(opts ...ServerOption) *Server {
 		reqDecoder:   RequestDecoder,
 		respEncoder:  ResponseEncoder,
 		errorEncoder: ErrorEncoder,
+		upgrader:     &WebSocketUpgrader{},
 	}
 
 	for _, opt := range opts {
@@ -154,8 +168,12 @@ func (s *Server) baseFilter() mux.MiddlewareFunc {
 				ctx    context.Context
 				cancel context.CancelFunc
 			)
-			if s.timeout > 0 {
-				ctx, cancel = context.WithTimeout(req.Context(), s.timeout)
+			timeout := s.timeout
+			if isStreamRequest(req) {
+				timeout = s.streamTimeout
+			}
+			if timeout > 0 {
+				ctx, cancel = context.WithTimeout(req.Context(), timeout)
 			} else {
 				ctx, cancel = context.WithCancel(req.Context())
 			}
@@ -183,6 +201,15 @@ func (s *Server) baseFilter() mux.MiddlewareFunc {
 	}
 }
 
+// isStreamRequest reports whether req asks for a long-lived response
+func isStreamRequest(req *http.Request) bool {
+	if websocket.IsWebSocketUpgrade(req) {
+		return true
+	}
+	accept := req.Header.Get("Accept")
+	return strings.Contains(accept, sseContentType) || strings.Contains(accept, ndjsonContentType)
+}
+
 func (s *Server) Start(ctx context.Context) error {
 	if err := s.listenAndEndpoint(); err != nil {
 		return err
@@ -1,7 +1,14 @@
 package http
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_Server(t *testing.T) {
@@ -15,3 +22,62 @@ func Test_Server(t *testing.T) {
 	//})
 	//srv.Start(context.Background())
 }
+
+func Test_ServerStreamTimeout(t *testing.T) {
+	deadline := func(ctx Context) string {
+		d, ok := ctx.Request().Context().Deadline()
+		if !ok {
+			return "none"
+		}
+		return time.Until(d).Round(time.Second).String()
+	}
+	newServer := func(opts ...ServerOption) *httptest.Server {
+		srv := NewServer(opts...)
+		srv.Route("/").GET("/deadline", func(ctx Context) error {
+			_, err := ctx.Response().Write([]byte(deadline(ctx)))
+			return err
+		})
+		srv.Route("/").WebSocket("/ws", func(ctx Context, conn *WebSocketConn) error {
+			return conn.WriteMessage(websocket.TextMessage, []byte(deadline(ctx)))
+		})
+		return httptest.NewServer(srv)
+	}
+	accept := func(t *testing.T, url, accept string) string {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err)
+		req.Header.Set("Accept", accept)
+		res, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		defer res.Body.Close()
+		body := make([]byte, 16)
+		n, _ := res.Body.Read(body)
+		return string(body[:n])
+	}
+	ws := func(t *testing.T, url string) string {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http")+"/ws", nil)
+		assert.Nil(t, err)
+		defer conn.Close()
+		_, msg, err := conn.ReadMessage()
+		assert.Nil(t, err)
+		return string(msg)
+	}
+
+	t.Run("default", func(t *testing.T) {
+		ts := newServer()
+		defer ts.Close()
+
+		assert.Equal(t, "2s", accept(t, ts.URL+"/deadline", "application/json"))
+		assert.Equal(t, "none", accept(t, ts.URL+"/deadline", sseContentType))
+		assert.Equal(t, "none", accept(t, ts.URL+"/deadline", ndjsonContentType))
+		assert.Equal(t, "none", ws(t, ts.URL))
+	})
+
+	t.Run("WithServerStreamTimeout", func(t *testing.T) {
+		ts := newServer(WithServerStreamTimeout(time.Minute))
+		defer ts.Close()
+
+		assert.Equal(t, "2s", accept(t, ts.URL+"/deadline", "application/json"))
+		assert.Equal(t, "1m0s", accept(t, ts.URL+"/deadline", sseContentType))
+		assert.Equal(t, "1m0s", ws(t, ts.URL))
+	})
+}
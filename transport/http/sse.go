@@ -0,0 +1,243 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/pkg/encoding"
+	"github.com/sado0823/go-kitx/pkg/encoding/json"
+)
+
+// ErrSSEUnsupported is returned when the response writer can not be flushed
+var ErrSSEUnsupported = errors.New("http: response writer does not support flushing")
+
+var errStreamClosed = errors.New("http: write to a closed stream")
+
+const defaultSSEHeartbeat = time.Second * 15
+
+type (
+	// SSEvent is a server-sent event, Data is written as it is when it's a string or []byte,
+	// otherwise it's encoded with the json codec
+	SSEvent struct {
+		ID    string
+		Event string
+		Retry time.Duration
+		Data  interface{}
+	}
+
+	SSEOption func(o *SSEWriter)
+
+	// SSEWriter writes server-sent events, every event is flushed once written,
+	// a comment is sent as heartbeat when nothing has been written for a while.
+	// The response header is written along with the first event, so the status code
+	// can still be changed before that. It must be closed before the handler returns
+	SSEWriter struct {
+		lock      sync.Mutex
+		w         http.ResponseWriter
+		flusher   http.Flusher
+		ctx       context.Context
+		codec     encoding.Codec
+		heartbeat time.Duration
+		written   time.Time
+		started   bool
+		done      chan struct{}
+		closeOnce sync.Once
+	}
+)
+
+// WithSSEHeartbeat set the heartbeat interval, default is 15s, 0 disables it
+func WithSSEHeartbeat(d time.Duration) SSEOption {
+	return func(o *SSEWriter) {
+		o.heartbeat = d
+	}
+}
+
+// WithSSEContext set the ctx of the request, the heartbeat stops and writes fail once it's done,
+// e.g. the client has gone or the handler has returned without Close
+func WithSSEContext(ctx context.Context) SSEOption {
+	return func(o *SSEWriter) {
+		o.ctx = ctx
+	}
+}
+
+// WithSSECodec set the codec of event data, default is json
+func WithSSECodec(c encoding.Codec) SSEOption {
+	return func(o *SSEWriter) {
+		o.codec = c
+	}
+}
+
+// NewSSEWriter returns a writer of the event stream
+func NewSSEWriter(w http.ResponseWriter, options ...SSEOption) (*SSEWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrSSEUnsupported
+	}
+	sw := &SSEWriter{
+		w:         w,
+		flusher:   flusher,
+		ctx:       context.Background(),
+		codec:     encoding.GetCodec(json.Name),
+		heartbeat: defaultSSEHeartbeat,
+		written:   time.Now(),
+		done:      make(chan struct{}),
+	}
+	for _, option := range options {
+		option(sw)
+	}
+
+	if sw.heartbeat > 0 {
+		go sw.keepalive()
+	}
+	return sw, nil
+}
+
+// Send writes the event and flushes it
+func (w *SSEWriter) Send(e SSEvent) error {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		writeSSEField(&buf, "id", e.ID)
+	}
+	if e.Event != "" {
+		writeSSEField(&buf, "event", e.Event)
+	}
+	if e.Retry > 0 {
+		writeSSEField(&buf, "retry", fmt.Sprint(e.Retry.Milliseconds()))
+	}
+	data, err := w.encode(e.Data)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(data, "\n") {
+		writeSSEField(&buf, "data", line)
+	}
+	buf.WriteByte('\n')
+	return w.write(buf.Bytes())
+}
+
+func (w *SSEWriter) writeMsg(m interface{}) error {
+	return w.Send(SSEvent{Data: m})
+}
+
+// Error sends the error as an `error` event, handlers use it once the stream has started,
+// when the error encoder can no longer write the status code
+func (w *SSEWriter) Error(err error) error {
+	return w.Send(SSEvent{Event: "error", Data: errorx.FromError(err)})
+}
+
+// Comment writes a comment line, which is ignored by clients
+func (w *SSEWriter) Comment(text string) error {
+	return w.write([]byte(": " + text + "\n\n"))
+}
+
+// Start writes the response header and flushes it if it has not been written
+func (w *SSEWriter) Start() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.start()
+}
+
+// Started reports whether the response header has been written
+func (w *SSEWriter) Started() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.started
+}
+
+// Close stops the heartbeat, it doesn't close the connection
+func (w *SSEWriter) Close() error {
+	w.closeOnce.Do(func() {
+		w.lock.Lock()
+		close(w.done)
+		w.lock.Unlock()
+	})
+	return nil
+}
+
+func (w *SSEWriter) encode(v interface{}) (string, error) {
+	switch data := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return data, nil
+	case []byte:
+		return string(data), nil
+	}
+	data, err := w.codec.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (w *SSEWriter) write(p []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if err := w.start(); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(p); err != nil {
+		return err
+	}
+	w.flusher.Flush()
+	w.written = time.Now()
+	return nil
+}
+
+func (w *SSEWriter) start() error {
+	select {
+	case <-w.done:
+		return errStreamClosed
+	case <-w.ctx.Done():
+		return errStreamClosed
+	default:
+	}
+	if w.started {
+		return nil
+	}
+	w.started = true
+	header := w.w.Header()
+	header.Set("Content-Type", sseContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.w.WriteHeader(http.StatusOK)
+	w.flusher.Flush()
+	return nil
+}
+
+func (w *SSEWriter) keepalive() {
+	ticker := time.NewTicker(w.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.lock.Lock()
+			idle := time.Since(w.written) >= w.heartbeat
+			w.lock.Unlock()
+			if idle {
+				_ = w.Comment("heartbeat")
+			}
+		}
+	}
+}
+
+func writeSSEField(buf *bytes.Buffer, name, value string) {
+	// line breaks would end the field
+	value = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(value)
+	buf.WriteString(name)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
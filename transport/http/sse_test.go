@@ -0,0 +1,105 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/transport/http/middleware"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func get(t *testing.T, url string) (*http.Response, string) {
+	res, err := http.Get(url)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	assert.Nil(t, err)
+	return res, string(body)
+}
+
+func TestContext_SSE(t *testing.T) {
+	srv := NewServer()
+	srv.Route("/").GET("/events", func(ctx Context) error {
+		w, err := ctx.SSE(WithSSEHeartbeat(0))
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+		assert.Nil(t, w.Send(SSEvent{ID: "1", Event: "greet", Retry: time.Second * 2, Data: map[string]string{"name": "kitx"}}))
+		assert.Nil(t, w.Send(SSEvent{Data: "a\nb"}))
+		assert.Nil(t, w.Comment("bye"))
+		return nil
+	})
+	srv.Route("/").GET("/heartbeat", func(ctx Context) error {
+		w, err := ctx.SSE(WithSSEHeartbeat(time.Millisecond * 20))
+		if err != nil {
+			return err
+		}
+		time.Sleep(time.Millisecond * 70)
+		assert.Nil(t, w.Close())
+		assert.NotNil(t, w.Send(SSEvent{Data: "closed"}))
+		return nil
+	})
+	srv.Route("/").GET("/filtered", func(ctx Context) error {
+		// filters wrapping the response writer must keep it flushable
+		w, err := ctx.SSE(WithSSEHeartbeat(0))
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+		return w.Send(SSEvent{Data: "filtered"})
+	}, middleware.Breaker())
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	res, body := get(t, ts.URL+"/events")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", res.Header.Get("Cache-Control"))
+	assert.Equal(t, "id: 1\nevent: greet\nretry: 2000\ndata: {\"name\":\"kitx\"}\n\ndata: a\ndata: b\n\n: bye\n\n", body)
+
+	res, body = get(t, ts.URL+"/filtered")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "data: filtered\n\n", body)
+
+	res, body = get(t, ts.URL+"/heartbeat")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.True(t, strings.HasPrefix(body, ": heartbeat\n\n"))
+	assert.NotContains(t, body, "closed")
+}
+
+func TestContext_SSEWithoutClose(t *testing.T) {
+	written := make(chan *SSEWriter, 1)
+	srv := NewServer()
+	srv.Route("/").GET("/events", func(ctx Context) error {
+		w, err := ctx.SSE(WithSSEHeartbeat(time.Millisecond * 10))
+		if err != nil {
+			return err
+		}
+		written <- w
+		return w.Send(SSEvent{Data: "once"})
+	})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	_, body := get(t, ts.URL+"/events")
+	assert.Equal(t, "data: once\n\n", body)
+
+	// the heartbeat stops with the request
+	w := <-written
+	assert.Eventually(t, func() bool {
+		return !keepaliveRunning()
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, errStreamClosed, w.Send(SSEvent{Data: "late"}))
+}
+
+func keepaliveRunning() bool {
+	buf := make([]byte, 1<<20)
+	return strings.Contains(string(buf[:runtime.Stack(buf, true)]), "(*SSEWriter).keepalive")
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sado0823/go-kitx/transport/pbchain"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	sseContentType    = "text/event-stream"
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// StreamFormat is how the messages of a server stream are written
+type StreamFormat string
+
+const (
+	// StreamSSE writes every message as a server-sent event
+	StreamSSE StreamFormat = "sse"
+	// StreamNDJSON writes every message as a line of newline-delimited json
+	StreamNDJSON StreamFormat = "ndjson"
+)
+
+var _ grpc.ServerStream = (*ServerStream)(nil)
+
+type (
+	// streamWriter writes the messages of a server stream
+	streamWriter interface {
+		writeMsg(m interface{}) error
+		Error(err error) error
+		Start() error
+		Started() bool
+		Close() error
+	}
+
+	// ServerStream adapts a server-streaming rpc onto an http response, it implements grpc.ServerStream,
+	// so that the same implementation serves both grpc and http. Every message sent runs the pbchain
+	// with pbchain.StreamSend before it's written
+	ServerStream struct {
+		ctx Context
+		// chainCtx is the ctx the pbchain passed to the handler
+		chainCtx context.Context
+		w        streamWriter
+	}
+)
+
+// ServeStream runs handler behind the pbchain with pbchain.StreamOpen, then the messages it sends are
+// written in the format asked by the Accept header, or the given one. An error returned before
+// the first message is encoded as usual, after that it's written to the stream since the status
+// code has been sent. Only requests accepting sse or ndjson get the server stream timeout
+func ServeStream(ctx Context, format StreamFormat, in interface{}, handler func(stream *ServerStream, req interface{}) error) error {
+	var (
+		w   streamWriter
+		err error
+	)
+	switch acceptFormat(ctx.Request().Header.Get("Accept"), format) {
+	case StreamNDJSON:
+		w, err = NewNDJSONWriter(ctx.Response())
+	default:
+		w, err = ctx.SSE()
+	}
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	stream := &ServerStream{ctx: ctx, chainCtx: ctx, w: w}
+	h := ctx.Middleware(func(ctx context.Context, req interface{}) (interface{}, error) {
+		stream.chainCtx = ctx
+		return nil, handler(stream, req)
+	})
+	if _, err = h(pbchain.NewStreamContext(ctx, pbchain.StreamOpen), in); err != nil {
+		if !w.Started() {
+			return err
+		}
+		return w.Error(err)
+	}
+	return w.Start()
+}
+
+func acceptFormat(accept string, format StreamFormat) StreamFormat {
+	switch {
+	case strings.Contains(accept, sseContentType):
+		return StreamSSE
+	case strings.Contains(accept, ndjsonContentType):
+		return StreamNDJSON
+	default:
+		return format
+	}
+}
+
+// Context returns the ctx built by the pbchain for the stream
+func (s *ServerStream) Context() context.Context {
+	return s.chainCtx
+}
+
+// SendMsg writes m to the stream
+func (s *ServerStream) SendMsg(m interface{}) error {
+	h := s.ctx.Middleware(func(_ context.Context, req interface{}) (interface{}, error) {
+		return req, s.w.writeMsg(req)
+	})
+	_, err := h(pbchain.NewStreamContext(s.chainCtx, pbchain.StreamSend), m)
+	return err
+}
+
+// RecvMsg is not supported, the request has been bound before the stream starts
+func (s *ServerStream) RecvMsg(m interface{}) error {
+	return errors.New("http: server stream does not receive messages")
+}
+
+// SetHeader sets the response header, it fails once the stream has started
+func (s *ServerStream) SetHeader(md metadata.MD) error {
+	if s.w.Started() {
+		return errors.New("http: server stream header has been sent")
+	}
+	header := s.ctx.Response().Header()
+	for k, vals := range md {
+		for _, v := range vals {
+			header.Add(k, v)
+		}
+	}
+	return nil
+}
+
+// SendHeader sets the response header and starts the stream
+func (s *ServerStream) SendHeader(md metadata.MD) error {
+	if err := s.SetHeader(md); err != nil {
+		return err
+	}
+	return s.w.Start()
+}
+
+// SetTrailer is ignored, http streams have no trailer
+func (s *ServerStream) SetTrailer(metadata.MD) {}
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/transport/pbchain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeStream(t *testing.T) {
+	var seen []string
+	srv := NewServer(WithServerPBChain(func(next pbchain.Handler) pbchain.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if d, ok := pbchain.FromStreamContext(ctx); ok {
+				seen = append(seen, d.String())
+			}
+			if req == "bad" {
+				return nil, errorx.BadRequest("BAD", "bad request")
+			}
+			return next(ctx, req)
+		}
+	}))
+	handler := func(ctx Context) error {
+		return ServeStream(ctx, StreamSSE, ctx.Query().Get("in"), func(stream *ServerStream, req interface{}) error {
+			assert.Nil(t, stream.SetHeader(map[string][]string{"x-stream": {"1"}}))
+			for i := 0; i < 2; i++ {
+				if err := stream.SendMsg(map[string]int{"i": i}); err != nil {
+					return err
+				}
+			}
+			assert.NotNil(t, stream.SetHeader(map[string][]string{"x-late": {"1"}}))
+			if req == "fail" {
+				return errorx.ServiceUnavailable("DOWN", "down")
+			}
+			return nil
+		})
+	}
+	srv.Route("/").GET("/stream", handler)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	res, body := get(t, ts.URL+"/stream?in=ok")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "1", res.Header.Get("x-stream"))
+	assert.Equal(t, "data: {\"i\":0}\n\ndata: {\"i\":1}\n\n", body)
+	assert.Equal(t, []string{"open", "send", "send"}, seen)
+
+	// rejected before the stream started
+	res, _ = get(t, ts.URL+"/stream?in=bad")
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	// failed after the stream started
+	res, body = get(t, ts.URL+"/stream?in=fail")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, body, "event: error\ndata: {")
+	assert.Contains(t, body, `"reason":"DOWN"`)
+}
+
+func TestClient_Stream(t *testing.T) {
+	var (
+		lock sync.Mutex
+		seen []string
+	)
+	srv := NewServer()
+	srv.Route("/").GET("/stream", func(ctx Context) error {
+		return ServeStream(ctx, StreamSSE, nil, func(stream *ServerStream, req interface{}) error {
+			for i := 0; i < 3; i++ {
+				if err := stream.SendMsg(map[string]int{"i": i}); err != nil {
+					return err
+				}
+			}
+			if ctx.Query().Get("fail") != "" {
+				return errorx.Conflict("CONFLICT", "conflict")
+			}
+			return nil
+		})
+	})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	client, err := NewClient(context.Background(),
+		WithClientEndpoint(ts.Listener.Addr().String()),
+		WithClientPBChain(func(next pbchain.Handler) pbchain.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				if d, ok := pbchain.FromStreamContext(ctx); ok {
+					lock.Lock()
+					seen = append(seen, d.String())
+					lock.Unlock()
+				}
+				return next(ctx, req)
+			}
+		}),
+	)
+	assert.Nil(t, err)
+
+	for _, format := range []StreamFormat{StreamSSE, StreamNDJSON} {
+		seen = nil
+		stream, err := client.Stream(context.Background(), http.MethodGet, "/stream", nil, format)
+		assert.Nil(t, err)
+		if format == StreamNDJSON {
+			assert.Equal(t, ndjsonContentType, stream.Header().Get("Content-Type"))
+		}
+		for i := 0; i < 3; i++ {
+			var m map[string]int
+			assert.Nil(t, stream.RecvMsg(&m))
+			assert.Equal(t, i, m["i"])
+		}
+		assert.Equal(t, io.EOF, stream.RecvMsg(&map[string]int{}))
+		assert.Nil(t, stream.Close())
+		assert.Equal(t, []string{"open", "recv", "recv", "recv"}, seen)
+
+		stream, err = client.Stream(context.Background(), http.MethodGet, "/stream?fail=1", nil, format)
+		assert.Nil(t, err)
+		for i := 0; i < 3; i++ {
+			assert.Nil(t, stream.RecvMsg(&map[string]int{}))
+		}
+		err = stream.RecvMsg(&map[string]int{})
+		assert.Equal(t, http.StatusConflict, errorx.Code(err))
+		assert.Equal(t, "CONFLICT", errorx.Reason(err))
+		assert.Nil(t, stream.Close())
+	}
+
+	_, err = client.Stream(context.Background(), http.MethodGet, "/not-found", nil, StreamSSE)
+	assert.Equal(t, http.StatusNotFound, errorx.Code(err))
+}
+
+type streamKey struct{}
+
+func TestServeStream_ChainContext(t *testing.T) {
+	var (
+		lock sync.Mutex
+		seen []string
+	)
+	// chainValue sets a ctx value when the stream opens and records the value seen by each message
+	chainValue := func(value string) pbchain.Middleware {
+		return func(next pbchain.Handler) pbchain.Handler {
+			return func(ctx context.Context, req interface{}) (interface{}, error) {
+				d, _ := pbchain.FromStreamContext(ctx)
+				if d == pbchain.StreamOpen {
+					return next(context.WithValue(ctx, streamKey{}, value), req)
+				}
+				v, _ := ctx.Value(streamKey{}).(string)
+				lock.Lock()
+				seen = append(seen, d.String()+":"+v)
+				lock.Unlock()
+				return next(ctx, req)
+			}
+		}
+	}
+
+	srv := NewServer(WithServerPBChain(chainValue("server")))
+	srv.Route("/").GET("/stream", func(ctx Context) error {
+		return ServeStream(ctx, StreamSSE, nil, func(stream *ServerStream, req interface{}) error {
+			v, _ := stream.Context().Value(streamKey{}).(string)
+			return stream.SendMsg(map[string]string{"value": v})
+		})
+	})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	client, err := NewClient(context.Background(),
+		WithClientEndpoint(ts.Listener.Addr().String()),
+		WithClientPBChain(chainValue("client")),
+	)
+	assert.Nil(t, err)
+
+	stream, err := client.Stream(context.Background(), http.MethodGet, "/stream", nil, StreamSSE)
+	assert.Nil(t, err)
+	defer stream.Close()
+	var m map[string]string
+	assert.Nil(t, stream.RecvMsg(&m))
+	assert.Equal(t, "server", m["value"])
+	assert.Equal(t, []string{"send:server", "recv:client"}, seen)
+}
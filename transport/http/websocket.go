@@ -0,0 +1,72 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/kit/log"
+
+	"github.com/gorilla/websocket"
+)
+
+type (
+	// WebSocketConn is an upgraded websocket connection
+	WebSocketConn = websocket.Conn
+
+	// WebSocketUpgrader upgrades http connections, CheckOrigin rejects cross origin requests by default
+	WebSocketUpgrader = websocket.Upgrader
+
+	// WebSocketHandlerFunc serves a websocket connection, the connection is closed once it returns,
+	// with a close frame carrying the reason of the returned error
+	WebSocketHandlerFunc func(Context, *WebSocketConn) error
+)
+
+// WithServerWebSocketUpgrader set the upgrader used by Router.WebSocket
+func WithServerWebSocketUpgrader(u *WebSocketUpgrader) ServerOption {
+	return func(o *Server) {
+		o.upgrader = u
+	}
+}
+
+// WebSocket registers a GET route upgrading to websocket, filters run before the upgrade,
+// so they can still reject the request with a status code.
+// ctx is canceled once the server stream timeout expires, see WithServerStreamTimeout
+func (r *Router) WebSocket(path string, h WebSocketHandlerFunc, filters ...FilterFunc) {
+	r.Handle(http.MethodGet, path, func(ctx Context) error {
+		conn, err := r.srv.upgrader.Upgrade(ctx.Response(), ctx.Request(), nil)
+		if err != nil {
+			// the upgrader has replied with the error
+			log.Context(ctx).Warnf("websocket upgrade fail: %v", err)
+			return nil
+		}
+		defer conn.Close()
+
+		err = h(ctx, conn)
+		_ = conn.WriteControl(websocket.CloseMessage, closeMessage(err), time.Now().Add(time.Second))
+		return nil
+	}, filters...)
+}
+
+func closeMessage(err error) []byte {
+	if err == nil {
+		return websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	}
+	var ce *websocket.CloseError
+	if errors.As(err, &ce) {
+		// closed by peer
+		return websocket.FormatCloseMessage(ce.Code, "")
+	}
+	code := websocket.CloseInternalServerErr
+	se := errorx.FromError(err)
+	if se.Code < http.StatusInternalServerError {
+		code = websocket.ClosePolicyViolation
+	}
+	// control frames are limited to 125 bytes
+	reason := se.Reason
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	return websocket.FormatCloseMessage(code, reason)
+}
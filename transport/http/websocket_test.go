@@ -0,0 +1,72 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/transport/http/middleware"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter_WebSocket(t *testing.T) {
+	srv := NewServer()
+	srv.Route("/").WebSocket("/ws", func(ctx Context, conn *WebSocketConn) error {
+		for {
+			mt, msg, err := conn.ReadMessage()
+			if err != nil {
+				return err
+			}
+			if string(msg) == "bye" {
+				return errorx.BadRequest("BYE", "bye")
+			}
+			if err = conn.WriteMessage(mt, append([]byte("echo "), msg...)); err != nil {
+				return err
+			}
+		}
+	})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte("hi")))
+	_, msg, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "echo hi", string(msg))
+
+	assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte("bye")))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation))
+	assert.Equal(t, "BYE", err.(*websocket.CloseError).Text)
+
+	// not an upgrade request
+	res, err := http.Get(ts.URL + "/ws")
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestRouter_WebSocketWithFilter(t *testing.T) {
+	srv := NewServer()
+	srv.Route("/").WebSocket("/ws", func(ctx Context, conn *WebSocketConn) error {
+		return conn.WriteMessage(websocket.TextMessage, []byte("filtered"))
+	}, middleware.Breaker())
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	_, msg, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "filtered", string(msg))
+}
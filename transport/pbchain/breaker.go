@@ -0,0 +1,55 @@
+package pbchain
+
+import (
+	"context"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/kit/breaker"
+	"github.com/sado0823/go-kitx/transport"
+)
+
+const ReasonBreaker = "CIRCUIT_BREAKER"
+
+// BreakerClient is a client side circuit breaker, each operation has its own breaker from breaker.Get,
+// options are used when the breaker is created.
+// only server errors (code >= 500) are counted as failures, streams and calls without
+// a client transport are passed through
+func BreakerClient(options ...breaker.OptionFn) Middleware {
+	return BreakerClientWithKey(operationKey, options...)
+}
+
+// BreakerClientWithKey is BreakerClient which names breakers by key, calls of the same name share
+// a breaker, calls named "" are passed through
+func BreakerClientWithKey(key func(ctx context.Context) string, options ...breaker.OptionFn) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+			if _, ok := FromStreamContext(ctx); ok {
+				return next(ctx, req)
+			}
+			name := key(ctx)
+			if name == "" {
+				return next(ctx, req)
+			}
+
+			err = breaker.Get(name, options...).DoWithFallback(func() error {
+				resp, err = next(ctx, req)
+				return err
+			}, func(err error) error {
+				return errorx.ServiceUnavailable(ReasonBreaker, "request rejected by circuit breaker").WithCause(err)
+			}, func(err error) bool {
+				return errorx.Code(err) < errorx.UnknownCode
+			})
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+	}
+}
+
+func operationKey(ctx context.Context) string {
+	if tr, ok := transport.FromClientContext(ctx); ok {
+		return tr.Operation()
+	}
+	return ""
+}
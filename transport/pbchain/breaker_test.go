@@ -0,0 +1,128 @@
+package pbchain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/kit/breaker"
+	"github.com/sado0823/go-kitx/transport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTransport struct {
+	operation string
+}
+
+func (m *mockTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (m *mockTransport) Endpoint() string                { return "" }
+func (m *mockTransport) Operation() string               { return m.operation }
+func (m *mockTransport) RequestHeader() transport.Header { return nil }
+func (m *mockTransport) ReplyHeader() transport.Header   { return nil }
+func (m *mockTransport) PathTemplate() string            { return "" }
+
+func TestBreakerClient(t *testing.T) {
+	var (
+		calls int
+		ctx   = transport.NewClientContext(context.Background(), &mockTransport{operation: "/test.Breaker/Client"})
+		h     = BreakerClient(breaker.WithClassic(breaker.WithClassicConsecutiveFailures(2)))(
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls++
+				if req == "bad request" {
+					return nil, errorx.BadRequest("BAD", "bad")
+				}
+				return nil, errorx.InternalServer("INTERNAL", "internal")
+			})
+	)
+
+	// client errors are not failures
+	for i := 0; i < 5; i++ {
+		_, err := h(ctx, "bad request")
+		assert.True(t, errorx.IsBadRequest(err))
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := h(ctx, nil)
+		assert.True(t, errorx.IsInternalServer(err))
+	}
+
+	_, err := h(ctx, nil)
+	assert.True(t, errorx.IsServiceUnavailable(err))
+	assert.Equal(t, ReasonBreaker, errorx.Reason(err))
+	assert.ErrorIs(t, err, breaker.ErrClassicBreakOn)
+	assert.Equal(t, 7, calls)
+
+	// other operations are not affected
+	_, err = h(transport.NewClientContext(context.Background(), &mockTransport{operation: "/test.Breaker/Other"}), "bad request")
+	assert.True(t, errorx.IsBadRequest(err))
+}
+
+func TestBreakerClient_Stream(t *testing.T) {
+	var (
+		calls int
+		ctx   = transport.NewClientContext(context.Background(), &mockTransport{operation: "/test.Breaker/Stream"})
+		h     = BreakerClient(breaker.WithClassic(breaker.WithClassicConsecutiveFailures(2)))(
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls++
+				return nil, errorx.InternalServer("INTERNAL", "internal")
+			})
+	)
+
+	// failures of streams never open the breaker
+	for _, d := range []StreamDirection{StreamOpen, StreamSend, StreamRecv, StreamOpen, StreamSend} {
+		_, err := h(NewStreamContext(ctx, d), nil)
+		assert.True(t, errorx.IsInternalServer(err))
+	}
+	assert.Equal(t, 5, calls)
+
+	// and unary calls are not affected
+	_, err := h(ctx, nil)
+	assert.True(t, errorx.IsInternalServer(err))
+}
+
+func TestBreakerClient_NoTransport(t *testing.T) {
+	var (
+		calls int
+		h     = BreakerClient(breaker.WithClassic(breaker.WithClassicConsecutiveFailures(2)))(
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls++
+				return nil, errorx.InternalServer("INTERNAL", "internal")
+			})
+	)
+
+	// calls without a transport don't share an unnamed breaker
+	for i := 0; i < 5; i++ {
+		_, err := h(context.Background(), nil)
+		assert.True(t, errorx.IsInternalServer(err))
+	}
+	assert.Equal(t, 5, calls)
+}
+
+func TestBreakerClientWithKey(t *testing.T) {
+	type tenantKey struct{}
+	var (
+		calls int
+		h     = BreakerClientWithKey(func(ctx context.Context) string {
+			tenant, _ := ctx.Value(tenantKey{}).(string)
+			return tenant
+		}, breaker.WithClassic(breaker.WithClassicConsecutiveFailures(2)))(
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls++
+				return nil, errorx.InternalServer("INTERNAL", "internal")
+			})
+		ctx = context.WithValue(context.Background(), tenantKey{}, "test-breaker-tenant-a")
+	)
+
+	for i := 0; i < 2; i++ {
+		_, err := h(ctx, nil)
+		assert.True(t, errorx.IsInternalServer(err))
+	}
+	_, err := h(ctx, nil)
+	assert.Equal(t, ReasonBreaker, errorx.Reason(err))
+	assert.Equal(t, 2, calls)
+
+	// other tenants are not affected
+	_, err = h(context.WithValue(context.Background(), tenantKey{}, "test-breaker-tenant-b"), nil)
+	assert.True(t, errorx.IsInternalServer(err))
+}
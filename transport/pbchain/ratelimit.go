@@ -0,0 +1,38 @@
+package pbchain
+
+import (
+	"context"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/kit/ratelimit"
+)
+
+const ReasonRateLimit = "RATELIMIT"
+
+// RateLimitServer is a server side admission control, ratelimit.NewBBR is used if limiter is nil.
+// streams are passed through, a slot held for the whole stream would starve unary calls
+func RateLimitServer(limiter ratelimit.Limiter) Middleware {
+	if limiter == nil {
+		limiter = ratelimit.NewBBR()
+	}
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req interface{}) (resp interface{}, err error) {
+			if _, ok := FromStreamContext(ctx); ok {
+				return next(ctx, req)
+			}
+			promise, err := limiter.Allow()
+			if err != nil {
+				return nil, errorx.ServiceUnavailable(ReasonRateLimit, "service overloaded").WithCause(err)
+			}
+
+			resp, err = next(ctx, req)
+			if errorx.Code(err) >= errorx.UnknownCode {
+				promise.MarkFail()
+			} else {
+				promise.MarkSuccess()
+			}
+			return resp, err
+		}
+	}
+}
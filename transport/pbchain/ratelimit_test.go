@@ -0,0 +1,66 @@
+package pbchain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/kit/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLimiter struct {
+	err       error
+	successes int
+	fails     int
+}
+
+func (m *mockLimiter) Allow() (ratelimit.Promise, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m, nil
+}
+
+func (m *mockLimiter) MarkSuccess() { m.successes++ }
+func (m *mockLimiter) MarkFail()    { m.fails++ }
+
+func TestRateLimitServer(t *testing.T) {
+	limiter := &mockLimiter{}
+	h := RateLimitServer(limiter)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		if req == nil {
+			return nil, errorx.InternalServer("INTERNAL", "internal")
+		}
+		return req, nil
+	})
+
+	resp, err := h(context.Background(), "foo")
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", resp)
+	_, err = h(context.Background(), nil)
+	assert.True(t, errorx.IsInternalServer(err))
+	assert.Equal(t, 1, limiter.successes)
+	assert.Equal(t, 1, limiter.fails)
+
+	limiter.err = errors.New("overload")
+	_, err = h(context.Background(), "foo")
+	assert.True(t, errorx.IsServiceUnavailable(err))
+	assert.Equal(t, ReasonRateLimit, errorx.Reason(err))
+	assert.ErrorIs(t, err, limiter.err)
+}
+
+func TestRateLimitServer_Stream(t *testing.T) {
+	limiter := &mockLimiter{err: errors.New("overload")}
+	h := RateLimitServer(limiter)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	})
+
+	for _, d := range []StreamDirection{StreamOpen, StreamSend, StreamRecv} {
+		resp, err := h(NewStreamContext(context.Background(), d), "foo")
+		assert.Nil(t, err)
+		assert.Equal(t, "foo", resp)
+	}
+	assert.Equal(t, 0, limiter.successes+limiter.fails)
+}
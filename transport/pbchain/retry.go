@@ -0,0 +1,25 @@
+package pbchain
+
+import (
+	"context"
+
+	"github.com/sado0823/go-kitx/kit/retry"
+)
+
+// RetryClient retries client requests with retry.Do, only transient errors
+// are retried by default, see retry.DefaultClassifier, override it with retry.WithClassifier.
+// share a retry.Budget between clients of the same backend to avoid retry storms.
+// streams are passed through, retrying would open the stream or send a message twice
+func RetryClient(options ...retry.Option) Middleware {
+	options = append([]retry.Option{retry.WithClassifier(retry.DefaultClassifier)}, options...)
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if _, ok := FromStreamContext(ctx); ok {
+				return next(ctx, req)
+			}
+			return retry.Do(ctx, func(ctx context.Context) (interface{}, error) {
+				return next(ctx, req)
+			}, options...)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package pbchain
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sado0823/go-kitx/errorx"
+	"github.com/sado0823/go-kitx/kit/retry"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryClient(t *testing.T) {
+	var (
+		calls int32
+		h     = RetryClient(retry.WithMin(time.Millisecond), retry.WithMax(time.Millisecond*5))(
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				if req == "bad request" {
+					atomic.AddInt32(&calls, 1)
+					return nil, errorx.BadRequest("BAD", "bad")
+				}
+				if atomic.AddInt32(&calls, 1) < 3 {
+					return nil, errorx.ServiceUnavailable("UNAVAILABLE", "unavailable")
+				}
+				return "reply", nil
+			})
+	)
+
+	resp, err := h(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "reply", resp)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// client errors are not retried
+	atomic.StoreInt32(&calls, 0)
+	_, err = h(context.Background(), "bad request")
+	assert.True(t, errorx.IsBadRequest(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRetryClientHedge(t *testing.T) {
+	var calls int32
+	h := RetryClient(retry.WithHedge(time.Millisecond * 10))(
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return req, nil
+		})
+
+	resp, err := h(context.Background(), "hedged")
+	assert.Nil(t, err)
+	assert.Equal(t, "hedged", resp)
+}
+
+func TestRetryClient_Stream(t *testing.T) {
+	var calls int32
+	h := RetryClient(retry.WithMin(time.Millisecond), retry.WithMax(time.Millisecond*5))(
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, errorx.ServiceUnavailable("UNAVAILABLE", "unavailable")
+		})
+
+	for _, d := range []StreamDirection{StreamOpen, StreamSend, StreamRecv} {
+		atomic.StoreInt32(&calls, 0)
+		_, err := h(NewStreamContext(context.Background(), d), nil)
+		assert.True(t, errorx.IsServiceUnavailable(err))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls), d.String())
+	}
+}
@@ -0,0 +1,70 @@
+package pbchain
+
+import "context"
+
+// StreamDirection tells which part of a stream the pbchain is running for
+type StreamDirection int
+
+const (
+	// StreamOpen the stream is being opened (client) or handled (server), req is nil
+	// unless the transport has decoded the request already, e.g. http
+	StreamOpen StreamDirection = iota + 1
+	// StreamSend a message is being sent, req is the message
+	StreamSend
+	// StreamRecv a message has been received, req is the message
+	StreamRecv
+)
+
+func (d StreamDirection) String() string {
+	switch d {
+	case StreamOpen:
+		return "open"
+	case StreamSend:
+		return "send"
+	case StreamRecv:
+		return "recv"
+	default:
+		return "unknown"
+	}
+}
+
+type streamKey struct{}
+
+// NewStreamContext returns a new Context that carries the stream direction
+func NewStreamContext(ctx context.Context, d StreamDirection) context.Context {
+	return context.WithValue(ctx, streamKey{}, d)
+}
+
+// FromStreamContext returns the stream direction, ok is false for unary calls
+func FromStreamContext(ctx context.Context) (d StreamDirection, ok bool) {
+	d, ok = ctx.Value(streamKey{}).(StreamDirection)
+	return
+}
+
+// Send runs the chain around sending a stream message
+func Send(ctx context.Context, ms []Middleware, msg interface{}, send func(msg interface{}) error) error {
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, send(req)
+	}
+	if len(ms) > 0 {
+		h = Chain(ms...)(h)
+	}
+	_, err := h(NewStreamContext(ctx, StreamSend), msg)
+	return err
+}
+
+// Recv receives a stream message then runs the chain with it,
+// so that validation and logging see the decoded message
+func Recv(ctx context.Context, ms []Middleware, msg interface{}, recv func(msg interface{}) error) error {
+	if err := recv(msg); err != nil {
+		return err
+	}
+	if len(ms) == 0 {
+		return nil
+	}
+	h := Chain(ms...)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	})
+	_, err := h(NewStreamContext(ctx, StreamRecv), msg)
+	return err
+}
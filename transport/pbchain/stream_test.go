@@ -0,0 +1,54 @@
+package pbchain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+	var seen []string
+	ms := []Middleware{func(next Handler) Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			d, ok := FromStreamContext(ctx)
+			assert.True(t, ok)
+			seen = append(seen, d.String()+":"+*req.(*string))
+			if *req.(*string) == "bad" {
+				return nil, errors.New("bad")
+			}
+			return next(ctx, req)
+		}
+	}}
+
+	sent := ""
+	msg := "hello"
+	assert.Nil(t, Send(context.Background(), ms, &msg, func(m interface{}) error {
+		sent = *m.(*string)
+		return nil
+	}))
+	assert.Equal(t, "hello", sent)
+
+	msg = "bad"
+	assert.EqualError(t, Send(context.Background(), ms, &msg, func(m interface{}) error {
+		t.Fatal("should not send")
+		return nil
+	}), "bad")
+
+	var got string
+	assert.Nil(t, Recv(context.Background(), ms, &got, func(m interface{}) error {
+		*m.(*string) = "world"
+		return nil
+	}))
+	assert.Equal(t, "world", got)
+
+	// the chain is not run when recv fails
+	assert.Equal(t, errors.New("eof"), Recv(context.Background(), ms, &got, func(m interface{}) error {
+		return errors.New("eof")
+	}))
+	assert.Equal(t, []string{"send:hello", "send:bad", "recv:world"}, seen)
+
+	_, ok := FromStreamContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,83 @@
+package selector
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	random struct {
+		r    *rand.Rand
+		lock sync.Mutex
+	}
+
+	roundRobin struct {
+		next uint64
+	}
+
+	p2c struct {
+		r    *rand.Rand
+		lock sync.Mutex
+	}
+)
+
+// NewRandom create a Balancer which picks a random node
+func NewRandom() Balancer {
+	return &random{r: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// NewRoundRobin create a Balancer which picks nodes in turn
+func NewRoundRobin() Balancer {
+	return &roundRobin{}
+}
+
+// NewP2C create a Balancer which picks the less loaded one of two random nodes by EWMA,
+// it's the same as grpc p2c balancer
+func NewP2C() Balancer {
+	return &p2c{r: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (r *random) Pick(ctx context.Context, nodes []*Node) (*Node, DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, ErrNoAvailable
+	}
+
+	r.lock.Lock()
+	node := nodes[r.r.Intn(len(nodes))]
+	r.lock.Unlock()
+	return node, start(node), nil
+}
+
+func (r *roundRobin) Pick(ctx context.Context, nodes []*Node) (*Node, DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, ErrNoAvailable
+	}
+
+	node := nodes[(atomic.AddUint64(&r.next, 1)-1)%uint64(len(nodes))]
+	return node, start(node), nil
+}
+
+func (p *p2c) Pick(ctx context.Context, nodes []*Node) (*Node, DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, ErrNoAvailable
+	}
+
+	p.lock.Lock()
+	node := nodes[P2C(p.r, len(nodes), func(i int) *EWMA {
+		return nodes[i].ewma
+	})]
+	p.lock.Unlock()
+	return node, start(node), nil
+}
+
+// start keeps EWMA of node up to date whatever the balancer is,
+// so switching balancers or mixing them is fine
+func start(node *Node) DoneFunc {
+	done := node.ewma.Start()
+	return func(ctx context.Context, info DoneInfo) {
+		done(info.Failed)
+	}
+}
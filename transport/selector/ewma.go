@@ -0,0 +1,156 @@
+package selector
+
+import (
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	decayTime = int64(time.Second * 10)
+
+	initSuccess     = 1000
+	throttleSuccess = initSuccess / 2
+	penalty         = int64(math.MaxInt32)
+	forcePick       = int64(time.Second)
+	pickTimes       = 3
+)
+
+var initTime = time.Now().AddDate(-1, -1, -1)
+
+// EWMA is the load of a node, it's shared by p2c balancers of grpc and http
+type EWMA struct {
+	lagEWMA uint64 // 请求耗时, 计算后的ewma
+
+	inFlight    int64  // 节点拥塞度, 正在处理的请求
+	successEWMA uint64 // 一段时间内此连接的健康状态, 计算后的ewma
+	requests    int64  // 请求量
+
+	lastLag  int64 // 上一次请求耗时, 用于计算ewma
+	pickTime int64 // 上一次选择的时间时间戳
+}
+
+func NewEWMA() *EWMA {
+	return &EWMA{successEWMA: initSuccess}
+}
+
+// Start a request on the node, the returned func should be called when it's done
+func (e *EWMA) Start() func(failed bool) {
+	atomic.AddInt64(&e.inFlight, 1)
+	atomic.AddInt64(&e.requests, 1)
+
+	start := int64(time.Since(initTime))
+	return func(failed bool) {
+		// 正在处理的请求数-1
+		atomic.AddInt64(&e.inFlight, -1)
+
+		// 计算相对时间
+		now := time.Since(initTime)
+		last := atomic.SwapInt64(&e.lastLag, int64(now))
+		td := int64(now) - last
+		if td < 0 {
+			td = 0
+		}
+
+		// 牛顿冷却定律的衰减模型, 确定ewma中的β值, β = 1/e^(k*△t)
+		beta := math.Exp(float64(-td) / float64(decayTime))
+		lag := int64(now) - start
+		if lag < 0 {
+			lag = 0
+		}
+		olag := atomic.LoadUint64(&e.lagEWMA)
+		if olag == 0 {
+			beta = 0
+		}
+
+		// 指数加权平均算法 vt = vt-1 * β + vt * (1 - β)
+		// 存储当前lagEWMA
+		atomic.StoreUint64(&e.lagEWMA, uint64(float64(olag)*beta+float64(lag)*(1-beta)))
+
+		success := initSuccess
+		if failed {
+			success = 0
+		}
+
+		oldSuccess := atomic.LoadUint64(&e.successEWMA)
+		// 指数加权平均算法 vt = vt-1 * β + vt * (1 - β)
+		// 存储当前successEWMA
+		atomic.StoreUint64(&e.successEWMA, uint64(float64(oldSuccess)*beta+float64(success)*(1-beta)))
+	}
+}
+
+// Requests returns requests count since last call and reset it
+func (e *EWMA) Requests() int64 {
+	return atomic.SwapInt64(&e.requests, 0)
+}
+
+func (e *EWMA) Healthy() bool {
+	return atomic.LoadUint64(&e.successEWMA) > throttleSuccess
+}
+
+// Load = lagEWMA * inFlight
+func (e *EWMA) Load() int64 {
+	lag := int64(math.Sqrt(float64(atomic.LoadUint64(&e.lagEWMA) + 1)))
+	load := lag * (atomic.LoadInt64(&e.inFlight) + 1)
+	if load == 0 {
+		// penalty是初始化没有数据时的惩罚值, 在没有被选过的情况下, 会强制选择一次
+		return penalty
+	}
+
+	return load
+}
+
+// P2C picks the index of the less loaded one of two random nodes, n should be greater than 0.
+// r is not safe for concurrent use, callers should hold a lock
+func P2C(r *rand.Rand, n int, ewma func(i int) *EWMA) int {
+	switch n {
+	case 1: // 一个节点, 直接返回
+		return choose(0, -1, ewma)
+	case 2: // 两个节点, 返回负载最低的节点
+		return choose(0, 1, ewma)
+	default: // 多个节点, 最多经常三次计算, 选择合适的节点
+		var a, b int
+		// 三次随机选择节点
+		for i := 0; i < pickTimes; i++ {
+			a = r.Intn(n)
+			b = r.Intn(n - 1)
+			if b >= a {
+				// 防止出现相同节点
+				b++
+			}
+
+			// 选出一次符合要求的节点则停止
+			if ewma(a).Healthy() && ewma(b).Healthy() {
+				break
+			}
+		}
+
+		return choose(a, b, ewma)
+	}
+}
+
+func choose(i1, i2 int, ewma func(i int) *EWMA) int {
+	start := int64(time.Since(initTime))
+	if i2 < 0 {
+		atomic.StoreInt64(&ewma(i1).pickTime, start)
+		return i1
+	}
+
+	c1, c2 := ewma(i1), ewma(i2)
+	// 优先选择负载低的
+	if c1.Load() > c2.Load() {
+		i1, i2 = i2, i1
+		c1, c2 = c2, c1
+	}
+
+	// 选择响应快的
+	// 如果在超时时间内节点没有被选中过, 则选择该节点
+	pick := atomic.LoadInt64(&c2.pickTime)
+	if start-pick > forcePick && atomic.CompareAndSwapInt64(&c2.pickTime, pick, start) {
+		return i2
+	}
+
+	atomic.StoreInt64(&c1.pickTime, start)
+	return i1
+}
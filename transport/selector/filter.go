@@ -0,0 +1,100 @@
+package selector
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// NodeFilter returns the nodes matched, nodes must not be modified
+type NodeFilter func(ctx context.Context, nodes []*Node) []*Node
+
+type filterKey struct{}
+
+// NewFilterContext returns a ctx carrying filters after the ones already in ctx,
+// balancers apply them per request
+func NewFilterContext(ctx context.Context, filters ...NodeFilter) context.Context {
+	if len(filters) == 0 {
+		return ctx
+	}
+	prev := FromFilterContext(ctx)
+	merged := make([]NodeFilter, 0, len(prev)+len(filters))
+	merged = append(append(merged, prev...), filters...)
+	return context.WithValue(ctx, filterKey{}, merged)
+}
+
+// FromFilterContext returns the filters carried by ctx
+func FromFilterContext(ctx context.Context) []NodeFilter {
+	if ctx == nil {
+		return nil
+	}
+	filters, _ := ctx.Value(filterKey{}).([]NodeFilter)
+	return filters
+}
+
+// Filter apply filters in order, all nodes are returned if none matches,
+// so a bad filter never takes the service down
+func Filter(ctx context.Context, nodes []*Node, filters ...NodeFilter) []*Node {
+	if len(filters) == 0 {
+		return nodes
+	}
+
+	filtered := nodes
+	for _, filter := range filters {
+		filtered = filter(ctx, filtered)
+	}
+	if len(filtered) == 0 {
+		return nodes
+	}
+	return filtered
+}
+
+// Version matches nodes of the version
+func Version(version string) NodeFilter {
+	return match(func(node *Node) bool {
+		return node.service != nil && node.service.Version == version
+	})
+}
+
+// Metadata matches nodes with metadata key of value, e.g. Metadata("zone", "local")
+func Metadata(key, value string) NodeFilter {
+	return match(func(node *Node) bool {
+		return node.service != nil && node.service.Metadata[key] == value
+	})
+}
+
+// Canary sends percent of requests to nodes with metadata key of value, and the others to the rest,
+// e.g. Canary("canary", "true", 10). the side is chosen per call, so it must be applied per request,
+// applied once per instance change it sends all requests to one side
+func Canary(key, value string, percent float64) NodeFilter {
+	var (
+		r    = rand.New(rand.NewSource(time.Now().UnixNano()))
+		lock sync.Mutex
+	)
+	isCanary := func(node *Node) bool {
+		return node.service != nil && node.service.Metadata[key] == value
+	}
+
+	return func(ctx context.Context, nodes []*Node) []*Node {
+		lock.Lock()
+		canary := r.Float64()*100 < percent
+		lock.Unlock()
+
+		return match(func(node *Node) bool {
+			return isCanary(node) == canary
+		})(ctx, nodes)
+	}
+}
+
+func match(fn func(node *Node) bool) NodeFilter {
+	return func(ctx context.Context, nodes []*Node) []*Node {
+		matched := make([]*Node, 0, len(nodes))
+		for _, node := range nodes {
+			if fn(node) {
+				matched = append(matched, node)
+			}
+		}
+		return matched
+	}
+}
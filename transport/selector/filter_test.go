@@ -0,0 +1,63 @@
+package selector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sado0823/go-kitx/kit/registry"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		v1    = NewNode("127.0.0.1:8001", &registry.Service{Version: "v1", Metadata: map[string]string{"zone": "a"}})
+		v2    = NewNode("127.0.0.1:8002", &registry.Service{Version: "v2", Metadata: map[string]string{"zone": "b"}})
+		v2a   = NewNode("127.0.0.1:8003", &registry.Service{Version: "v2", Metadata: map[string]string{"zone": "a"}})
+		raw   = NewNode("127.0.0.1:8004", nil)
+		nodes = []*Node{v1, v2, v2a, raw}
+	)
+
+	assert.Equal(t, nodes, Filter(ctx, nodes))
+	assert.Equal(t, []*Node{v2, v2a}, Filter(ctx, nodes, Version("v2")))
+	assert.Equal(t, []*Node{v1, v2a}, Filter(ctx, nodes, Metadata("zone", "a")))
+	assert.Equal(t, []*Node{v2a}, Filter(ctx, nodes, Version("v2"), Metadata("zone", "a")))
+
+	// fallback to all
+	assert.Equal(t, nodes, Filter(ctx, nodes, Version("v3")))
+	assert.Equal(t, nodes, Filter(ctx, nodes, Version("v1"), Metadata("zone", "b")))
+}
+
+func TestCanary(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		canary = NewNode("127.0.0.1:8001", &registry.Service{Metadata: map[string]string{"canary": "true"}})
+		stable = NewNode("127.0.0.1:8002", &registry.Service{})
+		nodes  = []*Node{canary, stable}
+		filter = Canary("canary", "true", 20)
+		hits   int
+	)
+
+	for i := 0; i < 10000; i++ {
+		filtered := Filter(ctx, nodes, filter)
+		assert.Len(t, filtered, 1)
+		if filtered[0] == canary {
+			hits++
+		}
+	}
+	assert.InDelta(t, 0.2, float64(hits)/10000, 0.03)
+
+	assert.Equal(t, []*Node{stable}, Filter(ctx, nodes, Canary("canary", "true", 0)))
+	assert.Equal(t, []*Node{canary}, Filter(ctx, nodes, Canary("canary", "true", 100)))
+}
+
+func TestFilterContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, FromFilterContext(ctx))
+	assert.Equal(t, ctx, NewFilterContext(ctx))
+
+	ctx = NewFilterContext(ctx, Version("v1"))
+	ctx = NewFilterContext(ctx, Metadata("zone", "a"), Version("v2"))
+	assert.Len(t, FromFilterContext(ctx), 3)
+}
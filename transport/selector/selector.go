@@ -0,0 +1,99 @@
+package selector
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/sado0823/go-kitx/kit/registry"
+)
+
+var ErrNoAvailable = errors.New("no available node")
+
+type (
+	// Balancer picks a node from nodes, done should be called when the request finished,
+	// nodes are never empty
+	Balancer interface {
+		Pick(ctx context.Context, nodes []*Node) (selected *Node, done DoneFunc, err error)
+	}
+
+	DoneInfo struct {
+		// Err is the error of request, nil if succeeded
+		Err error
+		// Failed reports the node is unhealthy for this request, e.g. connection refused or 5xx
+		Failed bool
+	}
+
+	DoneFunc func(ctx context.Context, info DoneInfo)
+
+	// Node is an instance of a service, it keeps stats of requests for balancers,
+	// so the same Node should be used for the same address across updates
+	Node struct {
+		address     string
+		service     *registry.Service
+		ewma        *EWMA
+		failedUntil int64
+	}
+)
+
+func NewNode(address string, service *registry.Service) *Node {
+	return &Node{address: address, service: service, ewma: NewEWMA()}
+}
+
+// Renew returns a node of the same address with service updated, stats are kept
+func (n *Node) Renew(service *registry.Service) *Node {
+	return &Node{
+		address:     n.address,
+		service:     service,
+		ewma:        n.ewma,
+		failedUntil: atomic.LoadInt64(&n.failedUntil),
+	}
+}
+
+// Address returns host:port of node
+func (n *Node) Address() string {
+	return n.address
+}
+
+// Service returns the service instance of node, it may be nil for static nodes
+func (n *Node) Service() *registry.Service {
+	return n.service
+}
+
+// EWMA returns load stats of node
+func (n *Node) EWMA() *EWMA {
+	return n.ewma
+}
+
+// MarkFailed skip the node for d
+func (n *Node) MarkFailed(d time.Duration) {
+	atomic.StoreInt64(&n.failedUntil, time.Now().Add(d).UnixNano())
+}
+
+// Available reports the node is not skipped
+func (n *Node) Available() bool {
+	return atomic.LoadInt64(&n.failedUntil) <= time.Now().UnixNano()
+}
+
+// Select pick with balancer from available nodes matched by filters,
+// all matched nodes are candidates if none is available
+func Select(ctx context.Context, balancer Balancer, nodes []*Node, filters ...NodeFilter) (*Node, DoneFunc, error) {
+	if len(nodes) == 0 {
+		return nil, nil, ErrNoAvailable
+	}
+
+	nodes = Filter(ctx, nodes, filters...)
+
+	available := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Available() {
+			available = append(available, node)
+		}
+	}
+	if len(available) == 0 {
+		available = nodes
+	}
+
+	return balancer.Pick(ctx, available)
+}
@@ -0,0 +1,106 @@
+package selector
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newNodes(n int) []*Node {
+	nodes := make([]*Node, n)
+	for i := range nodes {
+		nodes[i] = NewNode("127.0.0.1:"+strconv.Itoa(8000+i), nil)
+	}
+	return nodes
+}
+
+func TestBalancers(t *testing.T) {
+	balancers := map[string]Balancer{
+		"random":      NewRandom(),
+		"round robin": NewRoundRobin(),
+		"p2c":         NewP2C(),
+	}
+
+	for name, balancer := range balancers {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, _, err := balancer.Pick(ctx, nil)
+			assert.ErrorIs(t, err, ErrNoAvailable)
+
+			nodes := newNodes(5)
+			picked := make(map[string]int)
+			for i := 0; i < 1000; i++ {
+				node, done, err := balancer.Pick(ctx, nodes)
+				assert.Nil(t, err)
+				picked[node.Address()]++
+				done(ctx, DoneInfo{})
+			}
+			assert.Len(t, picked, 5)
+		})
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		nodes    = newNodes(3)
+		balancer = NewRoundRobin()
+	)
+	for i := 0; i < 6; i++ {
+		node, _, err := balancer.Pick(ctx, nodes)
+		assert.Nil(t, err)
+		assert.Equal(t, nodes[i%3], node)
+	}
+}
+
+func TestP2C_Unhealthy(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		nodes    = newNodes(2)
+		balancer = NewP2C()
+	)
+	for i := 0; i < 100; i++ {
+		node, done, _ := balancer.Pick(ctx, nodes)
+		if node == nodes[0] {
+			done(ctx, DoneInfo{Err: errors.New("failed"), Failed: true})
+		} else {
+			done(ctx, DoneInfo{})
+		}
+	}
+	assert.False(t, nodes[0].EWMA().Healthy())
+	assert.True(t, nodes[1].EWMA().Healthy())
+}
+
+func TestSelect(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		nodes    = newNodes(2)
+		balancer = NewRoundRobin()
+	)
+
+	_, _, err := Select(ctx, balancer, nil)
+	assert.ErrorIs(t, err, ErrNoAvailable)
+
+	nodes[0].MarkFailed(time.Minute)
+	assert.False(t, nodes[0].Available())
+	for i := 0; i < 10; i++ {
+		node, _, err := Select(ctx, balancer, nodes)
+		assert.Nil(t, err)
+		assert.Equal(t, nodes[1], node)
+	}
+
+	// all failed, try them anyway
+	nodes[1].MarkFailed(time.Minute)
+	node, _, err := Select(ctx, balancer, nodes)
+	assert.Nil(t, err)
+	assert.NotNil(t, node)
+
+	// stats are kept
+	renewed := nodes[0].Renew(nil)
+	assert.False(t, renewed.Available())
+	assert.Equal(t, nodes[0].EWMA(), renewed.EWMA())
+}